@@ -1,10 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/hmac"
 	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"math"
+	"net"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"earthshaker/api/config"
@@ -14,38 +26,430 @@ import (
 	"earthshaker/api/payload"
 
 	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+//LiveMatchLimit : max number of matches in the spectator list
+const LiveMatchLimit = 50
+
+type contextKey string
+
+const deviceIDKey = contextKey("device_id")
+const playerIDKey = contextKey("player_id")
+const tokenGenerationKey = contextKey("token_generation")
+const adminKey = contextKey("admin")
+const requestIDKey = contextKey("request_id")
+
+//KeyRefreshInterval : how often the app keys are reloaded
+const KeyRefreshInterval = time.Minute
+
+//AdminSearchLimit : max page size of admin searches
+const AdminSearchLimit = 100
+
+//LeaderboardLimit : max page size of the leaderboard
+const LeaderboardLimit = 100
+
+//LeaderboardAroundSize : default and max number of players on each side of the caller
+const (
+	LeaderboardAroundSize    = 5
+	LeaderboardAroundMaxSize = 25
+)
+
+//NationStatsTTL : how long the nation aggregates are cached
+const NationStatsTTL = 10 * time.Minute
+
+//NationActivePeriod : players and matches updated within it count in the nation aggregates
+const NationActivePeriod = 30 * 24 * time.Hour
+
+//MatchHistoryLimit : max page size of the match history
+const MatchHistoryLimit = 50
+
+//FavoriteOpponentLimit : number of the most played opponents in the player stats
+const FavoriteOpponentLimit = 5
+
+//DeviceClaimTTL : how long a device claim code can be used
+const DeviceClaimTTL = 24 * time.Hour
+
+//MaxSignedBodyBytes : max size of a request body read to check its signature
+const MaxSignedBodyBytes = 1 << 20
+
+//MaxFriends : max number of friends of a player
+const MaxFriends = 200
+
+//FriendFeedLimit : max page size of the friend feed
+const FriendFeedLimit = 50
+
+//MaxReportLength : max length of the message of a report
+const MaxReportLength = 1000
+
 var cfg = config.Config{}
+var nonceCache *helper.NonceCache
+var keySet = helper.KeySet{}
+var keyMetrics = expvar.NewMap("app_key_requests")
+var keyDAO = dao.KeyDAO{}
+var deviceDAO = dao.DeviceDAO{}
 var statusDAO = dao.StatusDAO{}
 var matchDAO = dao.MatchDAO{}
+var replayDAO = dao.ReplayDAO{}
+var sanctionDAO = dao.SanctionDAO{}
+var auditDAO = dao.AuditDAO{}
+var rateLimitDAO = dao.RateLimitDAO{}
+var reportDAO = dao.ReportDAO{}
+var rateStore helper.RateStore
+var nationStatsCache = helper.NewValueCache(NationStatsTTL)
+var seasonDAO = dao.SeasonDAO{}
+var seasonRecordDAO = dao.SeasonRecordDAO{}
+var snapshotDAO = dao.SnapshotDAO{}
+var achievementDAO = dao.AchievementDAO{}
+var relationDAO = dao.RelationDAO{}
+var accountDAO = dao.AccountDAO{}
+var linkCodeDAO = dao.LinkCodeDAO{}
+var profileDAO = dao.ProfileDAO{}
+
+//RegisterDeviceEndPoint : Register a new device and issue its token
+func RegisterDeviceEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqRegisterDevice
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	if len(reqPayload.DeviceID) == 0 {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return
+	}
+	// - A device which played before devices were registered has to be claimed,
+	// so nobody else can register its id first
+	played, err := statusDAO.Exist(reqPayload.DeviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if played {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Device must be claimed"})
+		return
+	}
+	secret, err := helper.NewDeviceSecret()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	device := models.Device{DeviceID: reqPayload.DeviceID, SecretHash: helper.HashDeviceSecret(secret)}
+	if err := deviceDAO.Register(device); err != nil {
+		if err.Error() == "Exist" {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Device is registered"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if err := accountDAO.Create(reqPayload.DeviceID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithDeviceToken(w, reqPayload.DeviceID, 0, secret)
+}
+
+//AuthenticateDeviceEndPoint : Issue a new token to a registered device which proves its secret
+func AuthenticateDeviceEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAuthenticateDevice
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	device, err := deviceDAO.FindByID(reqPayload.DeviceID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if err != nil || !helper.MatchDeviceSecret(reqPayload.DeviceSecret, device.SecretHash) {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Invalid device credentials"})
+		return
+	}
+	RespondWithDeviceToken(w, device.DeviceID, device.TokenGeneration, "")
+}
+
+//ClaimDeviceEndPoint : Set a new secret of a device with a claim code issued by an admin, and issue its token
+func ClaimDeviceEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqClaimDevice
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	if len(reqPayload.DeviceID) == 0 || len(reqPayload.ClaimCode) == 0 {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Invalid claim code"})
+		return
+	}
+	secret, err := helper.NewDeviceSecret()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	claimHash := helper.HashDeviceSecret(reqPayload.ClaimCode)
+	generation, err := deviceDAO.Claim(reqPayload.DeviceID, claimHash, helper.HashDeviceSecret(secret), time.Now())
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Invalid claim code"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	// - A claimed device plays as its own player until it is linked
+	if err := accountDAO.Create(reqPayload.DeviceID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithDeviceToken(w, reqPayload.DeviceID, generation, secret)
+}
+
+//RefreshDeviceTokenEndPoint : Issue a new token to the device of the current token
+func RefreshDeviceTokenEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	RespondWithDeviceToken(w, DeviceIDOf(r), TokenGenerationOf(r), "")
+}
+
+//RotateDeviceSecretEndPoint : Replace the secret of the device of the current token and revoke its other tokens,
+//devices registered before secrets were issued get their first one this way.
+func RotateDeviceSecretEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	secret, err := helper.NewDeviceSecret()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	generation, err := deviceDAO.SetSecret(DeviceIDOf(r), helper.HashDeviceSecret(secret))
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Device is not registered"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithDeviceToken(w, DeviceIDOf(r), generation, secret)
+}
+
+//GetAccountEndPoint : The player id and the devices of the account of the caller
+func GetAccountEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	acc, err := accountDAO.FindByDevice(DeviceIDOf(r))
+	if err != nil {
+		if err.Error() != "NotFound" {
+			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+			return
+		}
+		acc = models.Account{PlayerID: PlayerIDOf(r), DeviceIDs: []string{DeviceIDOf(r)}}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: payload.ResAccount{
+		PlayerID:  acc.PlayerID,
+		DeviceIDs: acc.DeviceIDs,
+	}})
+}
+
+//CreateLinkCodeEndPoint : Issue a one-time code to link another device to the account of the caller
+func CreateLinkCodeEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	code, err := helper.NewLinkCode()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	expiresTime := time.Now().Add(time.Duration(cfg.LinkCodeTTLMinutes) * time.Minute)
+	err = linkCodeDAO.Insert(models.LinkCode{
+		Code:        code,
+		PlayerID:    PlayerIDOf(r),
+		CreatedBy:   DeviceIDOf(r),
+		ExpiresTime: expiresTime,
+	})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: payload.ResLinkCode{
+		Code:      code,
+		ExpiresAt: expiresTime.Unix(),
+	}})
+}
+
+//LinkDeviceEndPoint : Link the device of the caller to the account of a link code,
+//the device then plays as the player of the account. Leaving a player with progress on no device needs leave_player.
+func LinkDeviceEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqLinkDevice
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := DeviceIDOf(r)
+	code := helper.NormalizeLinkCode(reqPayload.Code)
+	now := time.Now()
+	lnk, err := linkCodeDAO.FindValid(code, now)
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Link code is invalid or expired"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	before := PlayerIDOf(r)
+	if lnk.PlayerID == before {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Device is linked to the account"})
+		return
+	}
+	if _, err := matchDAO.FindStartedMatchOf(before); err == nil {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Device is in a match"})
+		return
+	} else if err.Error() != "NotFound" {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if !reqPayload.LeavePlayer {
+		leaving, err := LeavesPlayerWithProgress(deviceID, before)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+			return
+		}
+		if leaving {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Device is the last device of a player with progress"})
+			return
+		}
+	}
+	acc, err := dao.LinkDevice(code, deviceID, now)
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Link code is invalid or expired"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	audit := DeviceAuditOf(r, models.AUDITLINKDEVICE, deviceID)
+	audit.Before = map[string]interface{}{"player_id": before}
+	audit.After = map[string]interface{}{"player_id": acc.PlayerID}
+	WriteAudit(audit)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: payload.ResAccount{
+		PlayerID:  acc.PlayerID,
+		DeviceIDs: acc.DeviceIDs,
+	}})
+}
+
+//LeavesPlayerWithProgress : check if linking the device leaves its player without devices while it has mmr or matches,
+//the player could then not be played any more.
+func LeavesPlayerWithProgress(deviceID string, playerID string) (bool, error) {
+	acc, err := accountDAO.FindByDevice(deviceID)
+	if err != nil && err.Error() != "NotFound" {
+		return false, err
+	}
+	if err == nil && len(acc.DeviceIDs) > 1 {
+		return false, nil
+	}
+	stt, err := statusDAO.FindByID(playerID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	if err == nil && stt.PlayerMMR != 0 {
+		return true, nil
+	}
+	return matchDAO.ExistMatchOf(playerID)
+}
+
+//RespondWithDeviceToken : the secret is only given when it is issued
+func RespondWithDeviceToken(w http.ResponseWriter, deviceID string, generation int64, secret string) {
+	issuedAt := time.Now()
+	ttl := time.Duration(cfg.TokenTTLHours) * time.Hour
+	token, err := helper.SignDeviceToken(cfg.TokenSecret, deviceID, generation, issuedAt, ttl)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResDeviceToken{
+		Token:        token,
+		ExpiresAt:    issuedAt.Add(ttl).Unix(),
+		SigningKey:   helper.DeviceSigningKey(cfg.TokenSecret, deviceID, generation),
+		DeviceSecret: secret,
+	})
+}
 
 //UpsertStatusEndPoint : If new device id => insert, otherwise update.
 func UpsertStatusEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	var reqPayload payload.ReqUpsertStatus
 	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
-		log.Fatal(err)
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
+	deviceID := PlayerIDOf(r)
+	if len(reqPayload.GameMode) > 0 && !cfg.IsGameMode(reqPayload.GameMode) {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid game mode"})
+		return
+	}
 	if reqPayload.PlayerStatus == models.WAITMATCH {
-		err := matchDAO.CleanMatchOf(reqPayload.DeviceID)
+		sanctions, err := sanctionDAO.FindActiveOfAny([]string{DeviceIDOf(r), deviceID})
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+			return
+		}
+		if sanction := helper.BlockingSanction(sanctions, cfg.GameModeOf(reqPayload.GameMode).Ranked); sanction != nil {
+			RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: helper.SanctionMessage(sanction)})
+			return
+		}
+		err = matchDAO.CleanMatchOf(deviceID)
 		if err != nil {
 			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 			return
 		}
 	}
 	var statusModel = models.Status{
-		DeviceID:     reqPayload.DeviceID,
+		DeviceID:     deviceID,
 		PlayerName:   reqPayload.PlayerName,
 		PlayerStatus: reqPayload.PlayerStatus,
 		PlayerNation: reqPayload.PlayerNation,
+		GameMode:     reqPayload.GameMode,
+	}
+	if len(reqPayload.PlayerName) > 0 {
+		if nameErr := helper.ValidateName(reqPayload.PlayerName, cfg.NameMinLength, cfg.NameMaxLength, cfg.NameDenylist); nameErr != nil {
+			RespondWithNameError(w, http.StatusUnprocessableEntity, nameErr)
+			return
+		}
+		statusModel.NameKey = helper.NameKey(reqPayload.PlayerName)
+		if cfg.UniqueNames {
+			taken, err := statusDAO.ExistName(statusModel.NameKey, deviceID)
+			if err != nil {
+				RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+				return
+			}
+			if taken {
+				RespondWithNameError(w, http.StatusConflict, &helper.NameError{Code: helper.NAMETAKEN, Detail: "Name is used by another player"})
+				return
+			}
+		}
+	}
+	before, err := statusDAO.FindByID(deviceID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
 	}
 	if err := statusDAO.Upsert(statusModel); err != nil {
+		if err.Error() == "NameTaken" {
+			RespondWithNameError(w, http.StatusConflict, &helper.NameError{Code: helper.NAMETAKEN, Detail: "Name is used by another player"})
+			return
+		}
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
+	audit := DeviceAuditOf(r, models.AUDITUPSERTSTATUS, deviceID)
+	// - The upsert keeps the fields missing from the request, so they stay as they were
+	after := StatusFields(before)
+	for k, v := range StatusFields(statusModel) {
+		after[k] = v
+	}
+	audit.Before, audit.After = dao.ChangedFields(StatusFields(before), after)
+	WriteAudit(audit)
 
 	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
 }
@@ -68,7 +472,8 @@ func GetMatchInfoEndPoint(w http.ResponseWriter, r *http.Request) {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-	mch, err := matchDAO.FindMatchOf(player.DeviceID)
+	deviceID := PlayerIDOf(r)
+	mch, err := matchDAO.FindMatchOf(deviceID)
 	if err != nil {
 		if err.Error() == "NotFound" {
 			RespondWithJSON(w, http.StatusOK, payload.ResFindMatch{})
@@ -105,7 +510,8 @@ func GetMatchReadyEndPoint(w http.ResponseWriter, r *http.Request) {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-	mch, err := matchDAO.IsReadyMatch(player.DeviceID, player.MatchID)
+	deviceID := PlayerIDOf(r)
+	mch, err := matchDAO.IsReadyMatch(deviceID, player.MatchID)
 	if err != nil {
 		if err.Error() == "NotReady" {
 			log.Println("Match is not ready")
@@ -115,263 +521,2254 @@ func GetMatchReadyEndPoint(w http.ResponseWriter, r *http.Request) {
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 	}
 	enemyID := mch.Device1ID
-	if player.DeviceID == mch.Device1ID {
+	if deviceID == mch.Device1ID {
 		enemyID = mch.Device2ID
 	}
-	enemy, err := statusDAO.FindByID(enemyID)
+	profiles, err := ProfilesOf([]string{enemyID})
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
+	enemy := profiles[enemyID]
+	gameMode := cfg.GameModeOf(mch.GameMode)
 	var response = payload.ResReadyMatch{
-		MatchID:     mch.ID.Hex(),
-		FirstTurn:   player.DeviceID == mch.FirstTurnID,
-		EnemyID:     enemy.DeviceID,
-		EnemyName:   enemy.PlayerName,
-		EnemyNation: enemy.PlayerNation,
+		MatchID:      mch.ID.Hex(),
+		FirstTurn:    deviceID == mch.FirstTurnID,
+		EnemyID:      enemy.DeviceID,
+		EnemyName:    enemy.PlayerName,
+		EnemyNation:  enemy.PlayerNation,
+		EnemyAvatar:  enemy.AvatarID,
+		EnemyTitle:   enemy.Title,
+		GameMode:     mch.GameMode,
+		TurnSeconds:  gameMode.TurnSeconds,
+		MatchSeconds: gameMode.MatchSeconds,
 	}
 	RespondWithJSON(w, http.StatusOK, response)
 
 }
 
-//SendWebRTCMsgEndPoint : Send a WebRTC offer/candidate/answer
-func SendWebRTCMsgEndPoint(w http.ResponseWriter, r *http.Request) {
+//ResumeMatchEndPoint : Resume the started match of a dropped player
+func ResumeMatchEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	var reqPayload payload.ReqSendRTCMessage
+	var reqPayload payload.ReqResumeMatch
 	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-
-	matchObjID, err := helper.HexToObjID(reqPayload.MatchID)
+	deviceID := PlayerIDOf(r)
+	mch, err := matchDAO.FindStartedMatchOf(deviceID)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		if err.Error() == "NotFound" {
+			RespondWithJSON(w, http.StatusOK, payload.ResResumeMatch{})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
-	var matchModel = models.Match{
-		ID: *matchObjID,
-	}
-	if reqPayload.WebRTCType == payload.WebRTCOfferType {
-		matchModel.WebRTCOffer = reqPayload.WebRTCMessage
-		matchModel.MatchStatus = models.WAIT
-	} else if reqPayload.WebRTCType == payload.WebRTCCandidatesType {
-		matchModel.WebRTCCandidates = reqPayload.WebRTCMessage
-	} else if reqPayload.WebRTCType == payload.WebRTCAnswerType {
-		matchModel.WebRTCAnswer = reqPayload.WebRTCMessage
-	} else {
-		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid message type"})
+	if len(mch.WinnerID) > 0 || len(mch.LoserID) > 0 {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match is over"})
 		return
 	}
 
-	if err := matchDAO.Upsert(matchModel); err != nil {
+	enemyID, lastSeen := mch.Device1ID, mch.Device2SeenTime
+	if deviceID == mch.Device1ID {
+		enemyID, lastSeen = mch.Device2ID, mch.Device1SeenTime
+	}
+	grace := time.Duration(cfg.ReconnectGraceSeconds) * time.Second
+	if !lastSeen.IsZero() && time.Since(lastSeen) > grace {
+		var matchModel = models.Match{
+			ID:        mch.ID,
+			WinnerID:  enemyID,
+			LoserID:   deviceID,
+			EndReason: models.FORFEIT,
+		}
+		if err := matchDAO.DecideResult(matchModel); err != nil {
+			if err.Error() == "Decided" {
+				RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match is over"})
+				return
+			}
+			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+			return
+		}
+		audit := DeviceAuditOf(r, models.AUDITRESOLVEMATCH, mch.ID.Hex())
+		audit.Before = map[string]interface{}{
+			"winner_id":  mch.WinnerID,
+			"loser_id":   mch.LoserID,
+			"end_reason": mch.EndReason,
+		}
+		audit.After = map[string]interface{}{
+			"winner_id":  matchModel.WinnerID,
+			"loser_id":   matchModel.LoserID,
+			"end_reason": matchModel.EndReason,
+		}
+		WriteAudit(audit)
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match is forfeited"})
+		return
+	}
+	if err := matchDAO.Touch(mch.ID.Hex(), deviceID); err != nil {
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
 
-	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+	profiles, err := ProfilesOf([]string{enemyID})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	enemy := profiles[enemyID]
+	var response = payload.ResResumeMatch{
+		MatchID:      mch.ID.Hex(),
+		FirstTurn:    deviceID == mch.FirstTurnID,
+		EnemyID:      enemy.DeviceID,
+		EnemyName:    enemy.PlayerName,
+		EnemyNation:  enemy.PlayerNation,
+		EnemyAvatar:  enemy.AvatarID,
+		EnemyTitle:   enemy.Title,
+		GraceSeconds: cfg.ReconnectGraceSeconds,
+		Moves:        []payload.ResMove{},
+	}
+	for _, mv := range mch.Moves {
+		if mv.Sequence > reqPayload.Sequence {
+			response.Moves = append(response.Moves, ToResMove(mv))
+		}
+	}
+	sort.Slice(response.Moves, func(i, j int) bool {
+		return response.Moves[i].Sequence < response.Moves[j].Sequence
+	})
+	RespondWithJSON(w, http.StatusOK, response)
 }
 
-//ReceiveWebRTCMsgEndPoint : Receive a WebRTC offer/candidate/answer
-func ReceiveWebRTCMsgEndPoint(w http.ResponseWriter, r *http.Request) {
+//GetMatchReplayEndPoint : Get the ordered move log of a finished match
+func GetMatchReplayEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	var reqPayload payload.ReqReceiveRTCMessage
+	var reqPayload payload.ReqReplay
 	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-
-	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	mch, err := FindReplay(reqPayload.MatchID)
 	if err != nil {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
 		return
 	}
 
-	var resPayload = payload.ResReceiveRTCMessage{
-		MatchID:    reqPayload.MatchID,
-		WebRTCType: reqPayload.WebRTCType,
-	}
-	if reqPayload.WebRTCType == payload.WebRTCOfferType {
-		resPayload.WebRTCMessage = match.WebRTCOffer
-	} else if reqPayload.WebRTCType == payload.WebRTCCandidatesType {
-		resPayload.WebRTCMessage = match.WebRTCCandidates
-	} else if reqPayload.WebRTCType == payload.WebRTCAnswerType {
-		resPayload.WebRTCMessage = match.WebRTCAnswer
-
-		match.MatchStatus = models.START
-		if err := matchDAO.Upsert(match); err != nil {
-			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
-			return
-		}
+	resPayload := payload.ResReplay{}
+	resPayload.MatchID = mch.ID.Hex()
+	resPayload.MatchStatus = mch.MatchStatus
+	resPayload.MatchDate = mch.CreatedTime.Format(time.RFC3339)
+	resPayload.EndDate = mch.UpdatedTime.Format(time.RFC3339)
+	resPayload.Device1ID = mch.Device1ID
+	resPayload.Device2ID = mch.Device2ID
+	resPayload.FirstTurnID = mch.FirstTurnID
+	resPayload.WinnerID = mch.WinnerID
+	resPayload.LoserID = mch.LoserID
+	resPayload.EndReason = mch.EndReason
+	resPayload.Moves = []payload.ResMove{}
+	for _, mv := range mch.Moves {
+		resPayload.Moves = append(resPayload.Moves, ToResMove(mv))
 	}
-
 	RespondWithJSON(w, http.StatusOK, resPayload)
 }
 
-//SendMoveEndPoint :
-func SendMoveEndPoint(w http.ResponseWriter, r *http.Request) {
+//ExportMatchReplayEndPoint : Download a finished match as a JSON Lines replay file
+func ExportMatchReplayEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	var reqPayload payload.ReqSendMove
+	var reqPayload payload.ReqReplay
 	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-
-	mv := models.Move{}
-	mv.DeviceID = reqPayload.DeviceID
-	mv.Sequence = reqPayload.Sequence
-	mv.Step = reqPayload.Step
-
-	err := matchDAO.AppendMove(reqPayload.MatchID, mv)
+	mch, err := FindReplay(reqPayload.MatchID)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
 		return
 	}
-	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+	w.Header().Set("Content-Type", helper.ReplayContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=\"match-"+mch.ID.Hex()+".jsonl\"")
+	w.WriteHeader(http.StatusOK)
+	if err := helper.WriteReplay(w, mch); err != nil {
+		log.Println(err)
+	}
 }
 
-//ReceiveMoveEndPoint :
-func ReceiveMoveEndPoint(w http.ResponseWriter, r *http.Request) {
+//ImportMatchReplayEndPoint : Load a JSON Lines replay file into the in-memory replay store
+func ImportMatchReplayEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	var reqPayload payload.ReqReceiveMove
-	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
-		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+	if r.Header.Get("Content-Type") != helper.ReplayContentType {
+		http.Error(w, "Content-Type header is not "+helper.ReplayContentType, http.StatusUnsupportedMediaType)
 		return
 	}
-
-	mch, err := matchDAO.FindMove(reqPayload.MatchID, reqPayload.Sequence)
+	mch, err := helper.ReadReplay(r.Body)
 	if err != nil {
-		if err.Error() == "NotFound" {
-			RespondWithJSON(w, http.StatusOK, payload.ResReceiveMove{})
-			return
-		}
-		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid replay file"})
 		return
 	}
-	resPayload := payload.ResReceiveMove{}
+	sort.SliceStable(mch.Moves, func(i, j int) bool {
+		return mch.Moves[i].Sequence < mch.Moves[j].Sequence
+	})
+	replayDAO.Save(mch)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: mch.ID.Hex()})
+}
+
+//FindReplay : find a finished match, imported replays first.
+func FindReplay(matchID string) (models.Match, error) {
+	mch, err := replayDAO.FindByID(matchID)
+	if err == nil {
+		return mch, nil
+	}
+	mch, err = matchDAO.FindByID(matchID)
+	if err != nil {
+		return mch, err
+	}
+	if mch.MatchStatus != models.END && mch.MatchStatus != models.INV {
+		return mch, errors.New("NotFinished")
+	}
+	sort.SliceStable(mch.Moves, func(i, j int) bool {
+		return mch.Moves[i].Sequence < mch.Moves[j].Sequence
+	})
+	return mch, nil
+}
+
+//ToResMove : convert a move to its response payload
+func ToResMove(mv models.Move) payload.ResMove {
+	resMove := payload.ResMove{
+		DeviceID: mv.DeviceID,
+		Sequence: mv.Sequence,
+		Step:     mv.Step,
+	}
+	if !mv.CreatedTime.IsZero() {
+		resMove.MoveTime = mv.CreatedTime.Format(time.RFC3339Nano)
+	}
+	return resMove
+}
+
+//SendWebRTCMsgEndPoint : Send a WebRTC offer/candidate/answer to the peer
+func SendWebRTCMsgEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqSendRTCMessage
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	if reqPayload.WebRTCType != payload.WebRTCOfferType &&
+		reqPayload.WebRTCType != payload.WebRTCCandidatesType &&
+		reqPayload.WebRTCType != payload.WebRTCAnswerType {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid message type"})
+		return
+	}
+
+	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+	if deviceID != match.Device1ID && deviceID != match.Device2ID {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Not a player of the match"})
+		return
+	}
+
+	var msg = models.WebRTCMessage{
+		FromID:      deviceID,
+		Type:        reqPayload.WebRTCType,
+		Message:     reqPayload.WebRTCMessage,
+		CreatedTime: time.Now(),
+	}
+	if msg.ToID = match.Device1ID; deviceID == match.Device1ID {
+		msg.ToID = match.Device2ID
+	}
+	if err := matchDAO.AppendWebRTCMessage(reqPayload.MatchID, msg); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match does not accept messages"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+}
+
+//ReceiveWebRTCMsgEndPoint : Receive the WebRTC offer/candidates/answer sent by the peer
+func ReceiveWebRTCMsgEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqReceiveRTCMessage
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+
+	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+	if deviceID != match.Device1ID && deviceID != match.Device2ID {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Not a player of the match"})
+		return
+	}
+
+	var resPayload = payload.ResReceiveRTCMessage{
+		MatchID:    reqPayload.MatchID,
+		WebRTCType: reqPayload.WebRTCType,
+	}
+	// - Offers and answers are replaced by the lastest one, candidates are trickled from the given index
+	var candidates []string
+	for _, msg := range match.WebRTCMessages {
+		if msg.ToID != deviceID || msg.Type != reqPayload.WebRTCType {
+			continue
+		}
+		if msg.Type == payload.WebRTCCandidatesType {
+			candidates = append(candidates, msg.Message)
+		} else {
+			resPayload.WebRTCMessage = msg.Message
+		}
+	}
+	if reqPayload.WebRTCType == payload.WebRTCCandidatesType {
+		resPayload.Next = len(candidates)
+		if reqPayload.Since >= 0 && reqPayload.Since < len(candidates) {
+			resPayload.WebRTCCandidates = candidates[reqPayload.Since:]
+		}
+	}
+
+	RespondWithJSON(w, http.StatusOK, resPayload)
+}
+
+//GetICEServersEndPoint : Issue short-lived TURN credentials to a player of the match
+func GetICEServersEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqICEServers
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+
+	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+	if deviceID != match.Device1ID && deviceID != match.Device2ID {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Not a player of the match"})
+		return
+	}
+	if match.MatchStatus != models.INIT && match.MatchStatus != models.WAIT && match.MatchStatus != models.START {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match is over"})
+		return
+	}
+
+	ttl := time.Duration(cfg.TURNTTLSeconds) * time.Second
+	username, credential := helper.TURNCredential(cfg.TURNSecret, deviceID, reqPayload.MatchID, time.Now().Add(ttl))
+	resPayload := payload.ResICEServers{}
+	resPayload.TTLSeconds = cfg.TURNTTLSeconds
+	resPayload.ICEServers = []payload.ResICEServer{}
+	for _, server := range cfg.ICEServers {
+		resServer := payload.ResICEServer{URLs: server.URLs}
+		if server.TURN {
+			resServer.Username = username
+			resServer.Credential = credential
+		}
+		resPayload.ICEServers = append(resPayload.ICEServers, resServer)
+	}
+	RespondWithJSON(w, http.StatusOK, resPayload)
+}
+
+//SendMoveEndPoint :
+func SendMoveEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqSendMove
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+
+	mch, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+	if deviceID != mch.Device1ID && deviceID != mch.Device2ID {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Not a player of the match"})
+		return
+	}
+	gameMode := cfg.GameModeOf(mch.GameMode)
+	if helper.TimedOutPlayer(mch, gameMode.TurnLimit(), gameMode.MatchLimit(), time.Now()) == deviceID {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Time is up"})
+		return
+	}
+
+	mv := models.Move{}
+	mv.DeviceID = deviceID
+	mv.Sequence = reqPayload.Sequence
+	mv.Step = reqPayload.Step
+	mv.CreatedTime = time.Now()
+
+	err = matchDAO.AppendMove(reqPayload.MatchID, mv)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if err := matchDAO.Touch(reqPayload.MatchID, deviceID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+}
+
+//ReceiveMoveEndPoint :
+func ReceiveMoveEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqReceiveMove
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	if err := matchDAO.Touch(reqPayload.MatchID, deviceID); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+
+	mch, err := matchDAO.FindMove(reqPayload.MatchID, deviceID, reqPayload.Sequence)
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithJSON(w, http.StatusOK, payload.ResReceiveMove{})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	resPayload := payload.ResReceiveMove{}
+	resPayload.MatchID = reqPayload.MatchID
+	resPayload.Sequence = reqPayload.Sequence
+	resPayload.Step = mch.Moves[0].Step
+	RespondWithJSON(w, http.StatusOK, resPayload)
+}
+
+//GetLiveMatchesEndPoint : Get the started matches that can be watched
+func GetLiveMatchesEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	matches, err := matchDAO.FindLiveMatches(LiveMatchLimit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	var playerIDs []string
+	for _, match := range matches {
+		playerIDs = append(playerIDs, match.Device1ID, match.Device2ID)
+	}
+	players, err := statusDAO.FindByIDs(playerIDs)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	playerOf := map[string]models.Status{}
+	for _, player := range players {
+		playerOf[player.DeviceID] = player
+	}
+
+	resPayload := payload.ResLiveMatches{}
+	resPayload.DelaySeconds = cfg.SpectatorDelaySeconds
+	resPayload.Matches = []payload.ResLiveMatch{}
+	for _, match := range matches {
+		resMatch := payload.ResLiveMatch{}
+		resMatch.MatchID = match.ID.Hex()
+		resMatch.GameMode = match.GameMode
+		resMatch.StartDate = match.StartedTime.Format(time.RFC3339)
+		resMatch.Player1ID = match.Device1ID
+		resMatch.Player1Name = playerOf[match.Device1ID].PlayerName
+		resMatch.Player1Nation = playerOf[match.Device1ID].PlayerNation
+		resMatch.Player2ID = match.Device2ID
+		resMatch.Player2Name = playerOf[match.Device2ID].PlayerName
+		resMatch.Player2Nation = playerOf[match.Device2ID].PlayerNation
+		resPayload.Matches = append(resPayload.Matches, resMatch)
+	}
+	RespondWithJSON(w, http.StatusOK, resPayload)
+}
+
+//SpectateMoveEndPoint : Receive a move of a live match after the spectator delay
+func SpectateMoveEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqSpectateMove
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+
+	before := time.Now().Add(-time.Duration(cfg.SpectatorDelaySeconds) * time.Second)
+	mch, err := matchDAO.FindDelayedMove(reqPayload.MatchID, reqPayload.Sequence, before)
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithJSON(w, http.StatusOK, payload.ResReceiveMove{})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	resPayload := payload.ResReceiveMove{}
 	resPayload.MatchID = reqPayload.MatchID
 	resPayload.Sequence = reqPayload.Sequence
 	resPayload.Step = mch.Moves[0].Step
 	RespondWithJSON(w, http.StatusOK, resPayload)
 }
 
-//UpdateMatchResultEndPoint : Update match result.
-func UpdateMatchResultEndPoint(w http.ResponseWriter, r *http.Request) {
+//UpdateMatchResultEndPoint : Update match result.
+func UpdateMatchResultEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqMatchResult
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+
+	matchObjID, err := helper.HexToObjID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+
+	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+
+	if match.MatchStatus == models.END || match.MatchStatus == models.ERR || match.MatchStatus == models.INV {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
+		return
+	}
+	if deviceID != match.Device1ID && deviceID != match.Device2ID {
+		RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Not a player of the match"})
+		return
+	}
+
+	var matchModel = models.Match{
+		ID: *matchObjID,
+	}
+
+	if reqPayload.Winner {
+		if len(match.WinnerID) > 0 {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
+			return
+		}
+		matchModel.WinnerID = deviceID
+	} else {
+		if len(match.LoserID) > 0 {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
+			return
+		}
+		matchModel.LoserID = deviceID
+	}
+
+	if err := matchDAO.DecideResult(matchModel); err != nil {
+		if err.Error() == "Decided" {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match result is decided"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	audit := DeviceAuditOf(r, models.AUDITREPORTRESULT, match.ID.Hex())
+	if reqPayload.Winner {
+		audit.Before = map[string]interface{}{"winner_id": match.WinnerID}
+		audit.After = map[string]interface{}{"winner_id": matchModel.WinnerID}
+	} else {
+		audit.Before = map[string]interface{}{"loser_id": match.LoserID}
+		audit.After = map[string]interface{}{"loser_id": matchModel.LoserID}
+	}
+	WriteAudit(audit)
+
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+}
+
+//ReportPlayerEndPoint : Report a player, optionally in a match both players played
+func ReportPlayerEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqReportPlayer
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	switch reqPayload.Category {
+	case models.CHEATING, models.ABUSIVENAME, models.HARASSMENT, models.OTHERREPORT:
+	default:
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid category"})
+		return
+	}
+	if len(reqPayload.Message) > MaxReportLength {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Message is too long"})
+		return
+	}
+	if len(reqPayload.ReportedID) == 0 || reqPayload.ReportedID == deviceID {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return
+	}
+	reported, err := statusDAO.FindByID(reqPayload.ReportedID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return
+	}
+	if len(reqPayload.MatchID) > 0 {
+		match, err := matchDAO.FindByID(reqPayload.MatchID)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+			return
+		}
+		players := []string{match.Device1ID, match.Device2ID}
+		sort.Strings(players)
+		reportPlayers := []string{deviceID, reqPayload.ReportedID}
+		sort.Strings(reportPlayers)
+		if players[0] != reportPlayers[0] || players[1] != reportPlayers[1] {
+			RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Not the players of the match"})
+			return
+		}
+	}
+	exist, err := reportDAO.ExistOpen(deviceID, reqPayload.ReportedID, reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if exist {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Player is reported"})
+		return
+	}
+
+	var reportModel = models.Report{
+		ReporterID:   deviceID,
+		ReportedID:   reqPayload.ReportedID,
+		ReportedName: reported.PlayerName,
+		MatchID:      reqPayload.MatchID,
+		Category:     reqPayload.Category,
+		Message:      reqPayload.Message,
+	}
+	reportID, err := reportDAO.Insert(reportModel)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: reportID})
+}
+
+//GetPlayerRankEndPoint :
+func GetPlayerRankEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqGetRank
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	topRank, err := statusDAO.FindTopRank()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	snapshot, err := RankSnapshotOf(deviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	matchLimit := reqPayload.MatchLimit
+	if matchLimit <= 0 || matchLimit > MatchHistoryLimit {
+		matchLimit = MatchHistoryLimit
+	}
+	lastestMatches, err := matchDAO.FindHistoryOf(deviceID, models.HistoryFilter{
+		MatchStatus: models.END,
+		SeasonID:    reqPayload.SeasonID,
+	}, nil, matchLimit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	playerIDs := []string{topRank.DeviceID}
+	for _, match := range lastestMatches {
+		playerIDs = append(playerIDs, match.OpponentID)
+	}
+	profiles, err := ProfilesOf(playerIDs)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	topProfile := profiles[topRank.DeviceID]
+	resPayload := payload.ResGetRank{}
+	resPayload.TopRankPlayerID = topRank.DeviceID
+	resPayload.TopRankPlayerName = topProfile.PlayerName
+	resPayload.TopRankPlayerNation = topProfile.PlayerNation
+	resPayload.TopRankPlayerAvatar = topProfile.AvatarID
+	resPayload.TopRankPlayerTitle = topProfile.Title
+	resPayload.YourCurrentRank = snapshot.Rank
+	resPayload.YourNationRank = snapshot.NationRank
+	resPayload.YourPercentile = snapshot.Percentile
+	resPayload.RankTime = snapshot.SnapshotTime.Format(time.RFC3339)
+	resPayload.RankFromSnapshot = snapshot.TotalPlayers > 0
+	resPayload.LastestMatches = []payload.ResGetRankMatch{}
+
+	for _, match := range lastestMatches {
+		enemy := profiles[match.OpponentID]
+		resPayload.LastestMatches = append(resPayload.LastestMatches, payload.ResGetRankMatch{
+			MatchID:     match.ID.Hex(),
+			MatchDate:   match.CreatedTime.Format(time.RFC3339),
+			EnemyID:     match.OpponentID,
+			EnemyName:   enemy.PlayerName,
+			EnemyNation: enemy.PlayerNation,
+			EnemyAvatar: enemy.AvatarID,
+			EnemyTitle:  enemy.Title,
+			Win:         match.WinnerID == deviceID,
+		})
+	}
+
+	RespondWithJSON(w, http.StatusOK, resPayload)
+}
+
+//GetProfileEndPoint : The profile of a player, the caller if none is given
+func GetProfileEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqGetProfile
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := reqPayload.DeviceID
+	if len(deviceID) == 0 {
+		deviceID = PlayerIDOf(r)
+	}
+	profiles, err := ProfilesOf([]string{deviceID})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: profiles[deviceID]})
+}
+
+//UpsertProfileEndPoint : Create or update the profile of the caller, each field is validated
+func UpsertProfileEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqUpsertProfile
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	profile, err := profileDAO.FindByID(deviceID)
+	if err != nil && err.Error() != "NotFound" {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	profile.DeviceID = deviceID
+	if reqPayload.AvatarID != nil {
+		profile.AvatarID = *reqPayload.AvatarID
+	}
+	if reqPayload.Title != nil {
+		profile.Title = *reqPayload.Title
+	}
+	if reqPayload.PlayerNation != nil {
+		profile.PlayerNation = strings.ToUpper(*reqPayload.PlayerNation)
+	}
+	if reqPayload.Bio != nil {
+		profile.Bio = strings.TrimSpace(*reqPayload.Bio)
+	}
+	titles, err := TitlesOf(deviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if profileErr := helper.ValidateProfile(profile, cfg.Avatars, titles, cfg.ProfileBioMaxLength); profileErr != nil {
+		RespondWithError(w, http.StatusUnprocessableEntity, payload.ResFieldError{
+			Result: "Invalid player profile",
+			Field:  profileErr.Field,
+			Code:   profileErr.Code,
+			Detail: profileErr.Detail,
+		})
+		return
+	}
+	if err := profileDAO.Upsert(profile); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	profiles, err := ProfilesOf([]string{deviceID})
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: profiles[deviceID]})
+}
+
+//DeleteProfileEndPoint : Delete the profile of the caller, the status is shown instead
+func DeleteProfileEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	if err := profileDAO.Delete(PlayerIDOf(r)); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Profile is not found"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//ProfilesOf : the profiles of the players, the name comes from the status,
+//so does the nation when the player has no preferred one.
+func ProfilesOf(deviceIDs []string) (map[string]payload.ResProfile, error) {
+	players, err := statusDAO.FindByIDs(deviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := profileDAO.FindByIDs(deviceIDs)
+	if err != nil {
+		return nil, err
+	}
+	resProfiles := map[string]payload.ResProfile{}
+	for _, deviceID := range deviceIDs {
+		resProfiles[deviceID] = payload.ResProfile{DeviceID: deviceID}
+	}
+	for _, player := range players {
+		resProfile := resProfiles[player.DeviceID]
+		resProfile.PlayerName = player.PlayerName
+		resProfile.PlayerNation = player.PlayerNation
+		resProfiles[player.DeviceID] = resProfile
+	}
+	for _, profile := range profiles {
+		resProfile := resProfiles[profile.DeviceID]
+		if len(profile.PlayerNation) > 0 {
+			resProfile.PlayerNation = profile.PlayerNation
+		}
+		resProfile.AvatarID = profile.AvatarID
+		resProfile.Title = profile.Title
+		resProfile.Bio = profile.Bio
+		resProfile.UpdatedTime = profile.UpdatedTime.Format(time.RFC3339)
+		for _, title := range cfg.Titles {
+			if title.ID == profile.Title {
+				resProfile.TitleName = title.Name
+			}
+		}
+		resProfiles[profile.DeviceID] = resProfile
+	}
+	return resProfiles, nil
+}
+
+//TitlesOf : the configured titles and whether the player unlocked them
+func TitlesOf(deviceID string) (map[string]bool, error) {
+	granted, err := achievementDAO.FindOf(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	earned := map[string]bool{}
+	for _, grant := range granted {
+		earned[grant.AchievementID] = true
+	}
+	titles := map[string]bool{}
+	for _, title := range cfg.Titles {
+		titles[title.ID] = len(title.Achievement) == 0 || earned[title.Achievement]
+	}
+	return titles, nil
+}
+
+//RankSnapshotOf : the rank snapshot of a player, the rank is counted now if the player has none yet
+func RankSnapshotOf(deviceID string) (models.RankSnapshot, error) {
+	snapshot, err := snapshotDAO.FindOf(deviceID)
+	if err == nil {
+		return snapshot, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return snapshot, err
+	}
+	snapshot = models.RankSnapshot{DeviceID: deviceID, SnapshotTime: time.Now()}
+	me, err := statusDAO.FindByID(deviceID)
+	if err != nil {
+		return snapshot, nil
+	}
+	if snapshot.Rank, err = statusDAO.RankIn(me, ""); err != nil {
+		return snapshot, err
+	}
+	if len(me.PlayerNation) > 0 {
+		if snapshot.NationRank, err = statusDAO.RankIn(me, me.PlayerNation); err != nil {
+			return snapshot, err
+		}
+	}
+	snapshot.PlayerMMR = me.PlayerMMR
+	return snapshot, nil
+}
+
+//GetMatchHistoryEndPoint : The matches of the caller, the lastest first, continued from the cursor of the last page
+func GetMatchHistoryEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqMatchHistory
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	limit := reqPayload.Limit
+	if limit <= 0 || limit > MatchHistoryLimit {
+		limit = MatchHistoryLimit
+	}
+	filter := models.HistoryFilter{
+		OpponentID:  reqPayload.OpponentID,
+		Result:      reqPayload.Result,
+		GameMode:    reqPayload.GameMode,
+		MatchStatus: reqPayload.MatchStatus,
+		SeasonID:    reqPayload.SeasonID,
+	}
+	switch filter.Result {
+	case "", models.WIN, models.LOSS, models.DRAW:
+	default:
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid result"})
+		return
+	}
+	var err error
+	if len(reqPayload.From) > 0 {
+		if filter.From, err = time.Parse(time.RFC3339, reqPayload.From); err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid from date"})
+			return
+		}
+	}
+	if len(reqPayload.To) > 0 {
+		if filter.To, err = time.Parse(time.RFC3339, reqPayload.To); err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid to date"})
+			return
+		}
+	}
+	var before *models.MatchHistory
+	if len(reqPayload.Cursor) > 0 {
+		createdTime, matchID, err := helper.DecodeHistoryCursor(reqPayload.Cursor)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid cursor"})
+			return
+		}
+		before = &models.MatchHistory{ID: matchID, CreatedTime: createdTime}
+	}
+
+	matches, err := matchDAO.FindHistoryOf(deviceID, filter, before, limit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	resPayload := payload.ResMatchHistory{Matches: []payload.ResHistoryMatch{}}
+	for _, match := range matches {
+		resPayload.Matches = append(resPayload.Matches, ToResHistoryMatch(match, deviceID))
+	}
+	if int64(len(matches)) == limit {
+		last := matches[len(matches)-1]
+		resPayload.Next = helper.EncodeHistoryCursor(last.CreatedTime, last.ID)
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resPayload})
+}
+
+//GetAchievementsEndPoint : The configured achievements and which of them a player earned, the caller if none is given
+func GetAchievementsEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqPlayerAchievements
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := reqPayload.DeviceID
+	if len(deviceID) == 0 {
+		deviceID = PlayerIDOf(r)
+	}
+	granted, err := achievementDAO.FindOf(deviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	grantOf := map[string]models.Achievement{}
+	for _, grant := range granted {
+		grantOf[grant.AchievementID] = grant
+	}
+	resPayload := []payload.ResAchievement{}
+	for _, achievement := range cfg.Achievements {
+		resAchievement := payload.ResAchievement{
+			ID:          achievement.ID,
+			Name:        achievement.Name,
+			Description: achievement.Description,
+		}
+		if grant, exist := grantOf[achievement.ID]; exist {
+			resAchievement.Earned = true
+			resAchievement.MatchID = grant.MatchID
+			resAchievement.GrantedTime = grant.GrantedTime.Format(time.RFC3339)
+		}
+		resPayload = append(resPayload, resAchievement)
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resPayload})
+}
+
+//DecodeRelationTarget : the caller and the target of a relation request, responds with an error if the target is invalid
+func DecodeRelationTarget(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqRelation
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return "", "", false
+	}
+	deviceID := PlayerIDOf(r)
+	if len(reqPayload.TargetID) == 0 || reqPayload.TargetID == deviceID {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return "", "", false
+	}
+	return deviceID, reqPayload.TargetID, true
+}
+
+//RequestFriendEndPoint : Send a friend request, a request to a player who sent one accepts it
+func RequestFriendEndPoint(w http.ResponseWriter, r *http.Request) {
+	deviceID, targetID, ok := DecodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+	exist, err := statusDAO.Exist(targetID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if !exist {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return
+	}
+	relations, err := relationDAO.FindBetween(deviceID, targetID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	requested := false
+	for _, relation := range relations {
+		switch {
+		case relation.RelationStatus == models.BLOCKED:
+			RespondWithError(w, http.StatusForbidden, payload.ResResult{Result: "Player is blocked"})
+			return
+		case relation.RelationStatus == models.FRIEND:
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Player is a friend"})
+			return
+		case relation.DeviceID == deviceID:
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Player is requested"})
+			return
+		default:
+			requested = true
+		}
+	}
+	friends, err := relationDAO.FindOf(deviceID, models.FRIEND)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if len(friends) >= MaxFriends {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Too many friends"})
+		return
+	}
+	if requested {
+		err = dao.AcceptFriend(deviceID, targetID)
+	} else {
+		err = relationDAO.Request(deviceID, targetID)
+	}
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//AcceptFriendEndPoint : Accept the friend request of a player
+func AcceptFriendEndPoint(w http.ResponseWriter, r *http.Request) {
+	deviceID, targetID, ok := DecodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+	friends, err := relationDAO.FindOf(deviceID, models.FRIEND)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if len(friends) >= MaxFriends {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Too many friends"})
+		return
+	}
+	if err := dao.AcceptFriend(deviceID, targetID); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Friend request is not found"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//DeclineFriendEndPoint : Decline the friend request of a player
+func DeclineFriendEndPoint(w http.ResponseWriter, r *http.Request) {
+	deviceID, targetID, ok := DecodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := relationDAO.Decline(deviceID, targetID); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Friend request is not found"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//RemoveFriendEndPoint : Remove a friend, or cancel a sent friend request
+func RemoveFriendEndPoint(w http.ResponseWriter, r *http.Request) {
+	deviceID, targetID, ok := DecodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := relationDAO.Remove(deviceID, targetID); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Friend is not found"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//BlockPlayerEndPoint : Block a player, it ends the friendship and requests between the players
+func BlockPlayerEndPoint(w http.ResponseWriter, r *http.Request) {
+	deviceID, targetID, ok := DecodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+	exist, err := statusDAO.Exist(targetID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if !exist {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return
+	}
+	if err := dao.BlockPlayer(deviceID, targetID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//UnblockPlayerEndPoint : Unblock a player
+func UnblockPlayerEndPoint(w http.ResponseWriter, r *http.Request) {
+	deviceID, targetID, ok := DecodeRelationTarget(w, r)
+	if !ok {
+		return
+	}
+	if err := relationDAO.Unblock(deviceID, targetID); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusNotFound, payload.ResResult{Result: "Player is not blocked"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "OK"})
+}
+
+//GetFriendsEndPoint : The friends of the caller with their presence, the friend requests and the blocked players
+func GetFriendsEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	deviceID := PlayerIDOf(r)
+	friends, err := relationDAO.FindOf(deviceID, models.FRIEND)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	requests, err := relationDAO.FindRequestsTo(deviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	sent, err := relationDAO.FindOf(deviceID, models.PENDINGFRIEND)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	blocked, err := relationDAO.FindOf(deviceID, models.BLOCKED)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	var playerIDs []string
+	for _, relations := range [][]models.Relation{friends, sent, blocked} {
+		for _, relation := range relations {
+			playerIDs = append(playerIDs, relation.TargetID)
+		}
+	}
+	for _, relation := range requests {
+		playerIDs = append(playerIDs, relation.DeviceID)
+	}
+	players, err := statusDAO.FindByIDs(playerIDs)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	statusOf := map[string]models.Status{}
+	for _, player := range players {
+		statusOf[player.DeviceID] = player
+	}
+	toResRelations := func(relations []models.Relation, incoming bool, presence bool) []payload.ResRelation {
+		resRelations := []payload.ResRelation{}
+		for _, relation := range relations {
+			playerID := relation.TargetID
+			if incoming {
+				playerID = relation.DeviceID
+			}
+			player := statusOf[playerID]
+			resRelation := payload.ResRelation{
+				DeviceID:     playerID,
+				PlayerName:   player.PlayerName,
+				PlayerNation: player.PlayerNation,
+				Since:        relation.UpdatedTime.Format(time.RFC3339),
+			}
+			if presence {
+				resRelation.PlayerStatus = player.PlayerStatus
+				if len(resRelation.PlayerStatus) == 0 {
+					resRelation.PlayerStatus = models.OFFLINE
+				}
+				if !player.UpdatedTime.IsZero() {
+					resRelation.LastSeen = player.UpdatedTime.Format(time.RFC3339)
+				}
+			}
+			resRelations = append(resRelations, resRelation)
+		}
+		return resRelations
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: payload.ResRelations{
+		Friends:  toResRelations(friends, false, true),
+		Requests: toResRelations(requests, true, false),
+		Sent:     toResRelations(sent, false, false),
+		Blocked:  toResRelations(blocked, false, false),
+	}})
+}
+
+//GetFriendFeedEndPoint : The lastest ended matches of the friends of the caller
+func GetFriendFeedEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqFriendFeed
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := PlayerIDOf(r)
+	limit := reqPayload.Limit
+	if limit <= 0 || limit > FriendFeedLimit {
+		limit = FriendFeedLimit
+	}
+	var before *models.Match
+	if len(reqPayload.Cursor) > 0 {
+		createdTime, matchID, err := helper.DecodeHistoryCursor(reqPayload.Cursor)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid cursor"})
+			return
+		}
+		before = &models.Match{ID: matchID, CreatedTime: createdTime}
+	}
+	friends, err := relationDAO.FindOf(deviceID, models.FRIEND)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	isFriend := map[string]bool{}
+	var friendIDs []string
+	for _, friend := range friends {
+		isFriend[friend.TargetID] = true
+		friendIDs = append(friendIDs, friend.TargetID)
+	}
+	matches, err := matchDAO.FindFeedOf(friendIDs, before, limit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	var playerIDs []string
+	for _, match := range matches {
+		playerIDs = append(playerIDs, match.Device1ID, match.Device2ID)
+	}
+	players, err := statusDAO.FindByIDs(playerIDs)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	nameOf := map[string]string{}
+	for _, player := range players {
+		nameOf[player.DeviceID] = player.PlayerName
+	}
+	resPayload := payload.ResFriendFeed{Matches: []payload.ResFeedMatch{}}
+	for _, match := range matches {
+		friendID, opponentID := match.Device1ID, match.Device2ID
+		if !isFriend[friendID] {
+			friendID, opponentID = opponentID, friendID
+		}
+		resMatch := payload.ResFeedMatch{
+			MatchID:      match.ID.Hex(),
+			MatchDate:    match.CreatedTime.Format(time.RFC3339),
+			GameMode:     match.GameMode,
+			SeasonID:     match.SeasonID,
+			Result:       models.DRAW,
+			EndReason:    match.EndReason,
+			FriendID:     friendID,
+			FriendName:   nameOf[friendID],
+			OpponentID:   opponentID,
+			OpponentName: nameOf[opponentID],
+		}
+		switch friendID {
+		case match.WinnerID:
+			resMatch.Result = models.WIN
+		case match.LoserID:
+			resMatch.Result = models.LOSS
+		}
+		resPayload.Matches = append(resPayload.Matches, resMatch)
+	}
+	if int64(len(matches)) == limit {
+		last := matches[len(matches)-1]
+		resPayload.Next = helper.EncodeHistoryCursor(last.CreatedTime, last.ID)
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resPayload})
+}
+
+//ToResHistoryMatch : the result is empty until the match is decided
+func ToResHistoryMatch(match models.MatchHistory, deviceID string) payload.ResHistoryMatch {
+	resMatch := payload.ResHistoryMatch{
+		MatchID:     match.ID.Hex(),
+		MatchDate:   match.CreatedTime.Format(time.RFC3339),
+		MatchStatus: match.MatchStatus,
+		GameMode:    match.GameMode,
+		SeasonID:    match.SeasonID,
+		EndReason:   match.EndReason,
+		EnemyID:     match.OpponentID,
+		EnemyName:   match.Opponent.PlayerName,
+		EnemyNation: match.Opponent.PlayerNation,
+		EnemyMMR:    match.Opponent.PlayerMMR,
+	}
+	switch {
+	case match.WinnerID == deviceID:
+		resMatch.Result = models.WIN
+	case match.LoserID == deviceID:
+		resMatch.Result = models.LOSS
+	case match.MatchStatus == models.END:
+		resMatch.Result = models.DRAW
+	}
+	return resMatch
+}
+
+//GetPlayerStatsEndPoint : The record, streaks and opponents of a player, the caller if none is given
+func GetPlayerStatsEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqPlayerStats
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	deviceID := reqPayload.DeviceID
+	if len(deviceID) == 0 {
+		deviceID = PlayerIDOf(r)
+	}
+	stats, err := matchDAO.AggregateStatsOf(deviceID, reqPayload.OpponentID, reqPayload.SeasonID, time.Time{}, FavoriteOpponentLimit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if stats.Games > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Games)
+	}
+	if len(reqPayload.OpponentID) > 0 && stats.HeadToHead == nil {
+		stats.HeadToHead = &models.OpponentStats{DeviceID: reqPayload.OpponentID}
+	}
+	if stats.Opponents == nil {
+		stats.Opponents = []models.OpponentStats{}
+	}
+
+	var opponentIDs []string
+	for _, opponent := range stats.Opponents {
+		opponentIDs = append(opponentIDs, opponent.DeviceID)
+	}
+	if stats.HeadToHead != nil {
+		opponentIDs = append(opponentIDs, stats.HeadToHead.DeviceID)
+	}
+	opponents, err := statusDAO.FindByIDs(opponentIDs)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	nameOf := map[string]string{}
+	for _, opponent := range opponents {
+		nameOf[opponent.DeviceID] = opponent.PlayerName
+	}
+	for idx := range stats.Opponents {
+		stats.Opponents[idx].PlayerName = nameOf[stats.Opponents[idx].DeviceID]
+	}
+	if stats.HeadToHead != nil {
+		stats.HeadToHead.PlayerName = nameOf[stats.HeadToHead.DeviceID]
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: stats})
+}
+
+//GetLeaderboardEndPoint : A page of players in rank order, of a nation if it is given, continued from the cursor of the last page
+func GetLeaderboardEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqLeaderboard
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	limit := reqPayload.Limit
+	if limit <= 0 || limit > LeaderboardLimit {
+		limit = LeaderboardLimit
+	}
+	if len(reqPayload.SeasonID) > 0 {
+		season, err := seasonDAO.FindByID(reqPayload.SeasonID)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid season id"})
+			return
+		}
+		if !season.ArchivedTime.IsZero() {
+			RespondWithSeasonLeaderboard(w, reqPayload, limit)
+			return
+		}
+	}
+	var after *models.Status
+	var rank int64 = 1
+	if len(reqPayload.Cursor) > 0 {
+		mmr, deviceID, cursorRank, err := helper.DecodeRankCursor(reqPayload.Cursor)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid cursor"})
+			return
+		}
+		after = &models.Status{DeviceID: deviceID, PlayerMMR: mmr}
+		rank = cursorRank + 1
+	}
+	players, err := statusDAO.FindRankedAfter(after, reqPayload.Nation, limit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	resPayload := payload.ResLeaderboard{Players: []payload.ResRankedPlayer{}}
+	for idx, player := range players {
+		resPayload.Players = append(resPayload.Players, ToResRankedPlayer(player, rank+int64(idx)))
+	}
+	if int64(len(players)) == limit {
+		last := resPayload.Players[len(resPayload.Players)-1]
+		resPayload.Next = helper.EncodeRankCursor(last.PlayerMMR, last.DeviceID, last.Rank)
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resPayload})
+}
+
+//RespondWithSeasonLeaderboard : the leaderboard of an archived season, by final rank
+func RespondWithSeasonLeaderboard(w http.ResponseWriter, reqPayload payload.ReqLeaderboard, limit int64) {
+	var rank int64
+	if len(reqPayload.Cursor) > 0 {
+		_, _, cursorRank, err := helper.DecodeRankCursor(reqPayload.Cursor)
+		if err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid cursor"})
+			return
+		}
+		rank = cursorRank
+	}
+	records, err := seasonRecordDAO.FindRankedAfter(reqPayload.SeasonID, reqPayload.Nation, rank, limit)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	resPayload := payload.ResLeaderboard{Players: []payload.ResRankedPlayer{}}
+	for _, record := range records {
+		resPlayer := payload.ResRankedPlayer{
+			Rank:         record.FinalRank,
+			DeviceID:     record.DeviceID,
+			PlayerName:   record.PlayerName,
+			PlayerNation: record.PlayerNation,
+			PlayerMMR:    record.FinalMMR,
+		}
+		if len(reqPayload.Nation) > 0 {
+			resPlayer.Rank = record.FinalNationRank
+		}
+		resPayload.Players = append(resPayload.Players, resPlayer)
+	}
+	if int64(len(records)) == limit {
+		last := resPayload.Players[len(resPayload.Players)-1]
+		resPayload.Next = helper.EncodeRankCursor(last.PlayerMMR, last.DeviceID, last.Rank)
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resPayload})
+}
+
+//GetLeaderboardAroundEndPoint : The players ranked just before and after the caller, in the nation of the caller if asked
+func GetLeaderboardAroundEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqLeaderboardAround
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	size := reqPayload.Size
+	if size <= 0 {
+		size = LeaderboardAroundSize
+	} else if size > LeaderboardAroundMaxSize {
+		size = LeaderboardAroundMaxSize
+	}
+	deviceID := PlayerIDOf(r)
+	me, err := statusDAO.FindByID(deviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Player has no status"})
+		return
+	}
+	var nation string
+	if reqPayload.InNation {
+		if len(me.PlayerNation) == 0 {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Player has no nation"})
+			return
+		}
+		nation = me.PlayerNation
+	}
+	rank, err := statusDAO.RankIn(me, nation)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	above, err := statusDAO.FindRankedBefore(me, nation, size)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	below, err := statusDAO.FindRankedAfter(&me, nation, size)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+
+	resPayload := payload.ResLeaderboard{Players: []payload.ResRankedPlayer{}}
+	for idx, player := range above {
+		resPayload.Players = append(resPayload.Players, ToResRankedPlayer(player, rank-int64(len(above)-idx)))
+	}
+	resPayload.Players = append(resPayload.Players, ToResRankedPlayer(me, rank))
+	for idx, player := range below {
+		resPayload.Players = append(resPayload.Players, ToResRankedPlayer(player, rank+int64(idx+1)))
+	}
+	if int64(len(below)) == size {
+		last := resPayload.Players[len(resPayload.Players)-1]
+		resPayload.Next = helper.EncodeRankCursor(last.PlayerMMR, last.DeviceID, last.Rank)
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resPayload})
+}
+
+//GetSeasonsEndPoint : All seasons, the lastest first
+func GetSeasonsEndPoint(w http.ResponseWriter, r *http.Request) {
+	seasons, err := seasonDAO.FindAll()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if seasons == nil {
+		seasons = []models.Season{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: seasons})
+}
+
+//GetSeasonRecordsEndPoint : The final rank and mmr of the caller in past seasons
+func GetSeasonRecordsEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	records, err := seasonRecordDAO.FindOf(PlayerIDOf(r))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if records == nil {
+		records = []models.SeasonRecord{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: records})
+}
+
+//GetNationStatsEndPoint : Players, active players, average mmr and win rate of every nation
+func GetNationStatsEndPoint(w http.ResponseWriter, r *http.Request) {
+	nations, loadedTime, err := nationStatsCache.Get(time.Now(), LoadNationStats)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: payload.ResNationStats{
+		Nations:     nations,
+		UpdatedTime: loadedTime.Format(time.RFC3339),
+	}})
+}
+
+//LoadNationStats : merge the player and match aggregates of the nations, the highest average mmr first
+func LoadNationStats() (interface{}, error) {
+	since := time.Now().Add(-NationActivePeriod)
+	nations, err := statusDAO.AggregateNations(since)
+	if err != nil {
+		return nil, err
+	}
+	results, err := matchDAO.AggregateNationResults(since)
+	if err != nil {
+		return nil, err
+	}
+	resultOf := map[string]models.NationStats{}
+	for _, result := range results {
+		resultOf[result.Nation] = result
+	}
+	for idx := range nations {
+		result := resultOf[nations[idx].Nation]
+		nations[idx].Wins = result.Wins
+		nations[idx].Games = result.Games
+		if result.Games > 0 {
+			nations[idx].WinRate = float64(result.Wins) / float64(result.Games)
+		}
+	}
+	sort.Slice(nations, func(i, j int) bool {
+		if nations[i].AverageMMR != nations[j].AverageMMR {
+			return nations[i].AverageMMR > nations[j].AverageMMR
+		}
+		return nations[i].Nation < nations[j].Nation
+	})
+	if nations == nil {
+		nations = []models.NationStats{}
+	}
+	return nations, nil
+}
+
+//ToResRankedPlayer :
+func ToResRankedPlayer(player models.Status, rank int64) payload.ResRankedPlayer {
+	return payload.ResRankedPlayer{
+		Rank:         rank,
+		DeviceID:     player.DeviceID,
+		PlayerName:   player.PlayerName,
+		PlayerNation: player.PlayerNation,
+		PlayerMMR:    player.PlayerMMR,
+	}
+}
+
+//AdminSearchPlayersEndPoint : Search players by device id, name or status
+func AdminSearchPlayersEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminSearchPlayers
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	players, err := statusDAO.Search(reqPayload.DeviceID, reqPayload.PlayerName, reqPayload.PlayerStatus,
+		reqPayload.Skip, AdminLimitOf(reqPayload.Limit))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if players == nil {
+		players = []models.Status{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: players})
+}
+
+//AdminSearchMatchesEndPoint : Search matches by device id, status or creation date
+func AdminSearchMatchesEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminSearchMatches
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	var from, to time.Time
+	var err error
+	if len(reqPayload.From) > 0 {
+		if from, err = time.Parse(time.RFC3339, reqPayload.From); err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid from date"})
+			return
+		}
+	}
+	if len(reqPayload.To) > 0 {
+		if to, err = time.Parse(time.RFC3339, reqPayload.To); err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid to date"})
+			return
+		}
+	}
+	matches, err := matchDAO.Search(reqPayload.DeviceID, reqPayload.MatchStatus, reqPayload.SeasonID, from, to,
+		reqPayload.Skip, AdminLimitOf(reqPayload.Limit))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if matches == nil {
+		matches = []models.Match{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: matches})
+}
+
+//AdminUpdateMatchStatusEndPoint : Force the status of a match
+func AdminUpdateMatchStatusEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminMatchStatus
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	switch reqPayload.MatchStatus {
+	case models.INIT, models.WAIT, models.START, models.END, models.ERR, models.INV:
+	default:
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match status"})
+		return
+	}
+	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+	audit := AdminAuditOf(r, models.AUDITMATCHSTATUS, match.ID.Hex())
+	audit.Before = map[string]interface{}{"match_status": match.MatchStatus}
+	match.MatchStatus = reqPayload.MatchStatus
+	if err := matchDAO.Upsert(models.Match{ID: match.ID, MatchStatus: match.MatchStatus}); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	audit.After = map[string]interface{}{"match_status": match.MatchStatus}
+	WriteAudit(audit)
+	log.Printf("Admin %s set match %s to %s", AdminOf(r).Name, match.ID.Hex(), match.MatchStatus)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+}
+
+//AdminAdjustMMREndPoint : Add a delta to the mmr of a player
+func AdminAdjustMMREndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminAdjustMMR
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	player, err := statusDAO.AdjustMMR(reqPayload.DeviceID, reqPayload.Delta)
+	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	audit := AdminAuditOf(r, models.AUDITADJUSTMMR, player.DeviceID)
+	audit.Before = map[string]interface{}{"player_mmr": player.PlayerMMR - reqPayload.Delta}
+	audit.After = map[string]interface{}{"player_mmr": player.PlayerMMR}
+	WriteAudit(audit)
+	log.Printf("Admin %s adjusted mmr of %s by %d", AdminOf(r).Name, player.DeviceID, reqPayload.Delta)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: player})
+}
+
+//AdminCreateDeviceClaimEndPoint : Issue a code to claim a device which played before devices were registered,
+//it is given to the player once the ownership is checked.
+func AdminCreateDeviceClaimEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminDeviceClaim
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	exist, err := statusDAO.Exist(reqPayload.DeviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if !exist {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid device id"})
+		return
+	}
+	code, err := helper.NewDeviceSecret()
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	expiresTime := time.Now().Add(DeviceClaimTTL)
+	if err := deviceDAO.SetClaim(reqPayload.DeviceID, helper.HashDeviceSecret(code), expiresTime); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	audit := AdminAuditOf(r, models.AUDITDEVICECLAIM, reqPayload.DeviceID)
+	audit.After = map[string]interface{}{"claim_expires_time": expiresTime}
+	WriteAudit(audit)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: payload.ResAdminDeviceClaim{
+		ClaimCode: code,
+		ExpiresAt: expiresTime.Unix(),
+	}})
+}
+
+//AdminResolveMatchEndPoint : Re-run the resolution of a match that did not end, optionally with a given winner.
+//A match being played can only be resolved with a winner or once a clock has run out.
+func AdminResolveMatchEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminResolveMatch
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	match, err := matchDAO.FindByID(reqPayload.MatchID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		return
+	}
+	if match.MatchStatus == models.END {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match is resolved"})
+		return
+	}
+	if len(reqPayload.WinnerID) > 0 {
+		if reqPayload.WinnerID != match.Device1ID && reqPayload.WinnerID != match.Device2ID {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid winner id"})
+			return
+		}
+		match.WinnerID = reqPayload.WinnerID
+		match.LoserID = ""
+	} else if match.MatchStatus == models.START && len(match.WinnerID) == 0 && len(match.LoserID) == 0 {
+		// - A started match without result is only ended when a clock has run out
+		gameMode := cfg.GameModeOf(match.GameMode)
+		loserID := helper.TimedOutPlayer(match, gameMode.TurnLimit(), gameMode.MatchLimit(), time.Now())
+		if len(loserID) == 0 {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Match is being played"})
+			return
+		}
+		match.LoserID = loserID
+		match.EndReason = models.TIMEOUT
+	}
+	if match.MatchStatus == models.ERR || match.MatchStatus == models.INV {
+		match.MatchStatus = models.START
+	}
+
+	match.UpdatedTime = time.Now()
+	var playerMMRs map[string]int64
+	if winnerID := helper.ResolveMatch(&match); len(winnerID) > 0 {
+		playerMMRs = map[string]int64{winnerID: 1}
+	}
+	if err := dao.VerifyAndUpdateMMR([]models.Match{match}, playerMMRs, AdminAuditOf(r, "", "")); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	log.Printf("Admin %s resolved match %s to %s", AdminOf(r).Name, match.ID.Hex(), match.MatchStatus)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: match.MatchStatus})
+}
+
+//AdminCreateSanctionEndPoint : Ban, suspend or restrict a player
+func AdminCreateSanctionEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminCreateSanction
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	if len(reqPayload.DeviceID) == 0 || len(reqPayload.Reason) == 0 {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
+		return
+	}
+	targetID, err := SanctionTargetOf(reqPayload.DeviceID)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	sanctionModel, err := NewSanction(r, targetID, reqPayload.SanctionType, reqPayload.Reason, reqPayload.DurationHours)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: err.Error()})
+		return
+	}
+	sanctionID, err := CreateSanction(r, sanctionModel)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: sanctionID})
+}
+
+//SanctionTargetOf : the player id a sanction of a device or player applies to, so it holds on every device of the account.
+func SanctionTargetOf(id string) (string, error) {
+	exist, err := accountDAO.ExistPlayer(id)
+	if err != nil || exist {
+		return id, err
+	}
+	return PlayerIDOfDevice(id)
+}
+
+//NewSanction : a sanction of the admin of the request, checking its type and duration
+func NewSanction(r *http.Request, deviceID string, sanctionType string, reason string, durationHours int64) (models.Sanction, error) {
+	var sanctionModel = models.Sanction{
+		DeviceID:     deviceID,
+		SanctionType: sanctionType,
+		Reason:       reason,
+		CreatedBy:    AdminOf(r).Name,
+	}
+	if durationHours < 0 {
+		return sanctionModel, errors.New("Invalid duration")
+	}
+	switch sanctionType {
+	case models.BAN, models.RANKEDBAN:
+	case models.SUSPENSION:
+		if durationHours == 0 {
+			return sanctionModel, errors.New("A suspension needs a duration")
+		}
+	default:
+		return sanctionModel, errors.New("Invalid sanction type")
+	}
+	if durationHours > 0 {
+		sanctionModel.ExpiredTime = time.Now().Add(time.Duration(durationHours) * time.Hour)
+	}
+	return sanctionModel, nil
+}
+
+//CreateSanction : insert and audit a sanction, returns its id
+func CreateSanction(r *http.Request, sanctionModel models.Sanction) (string, error) {
+	sanctionID, err := sanctionDAO.Insert(sanctionModel)
+	if err != nil {
+		return "", err
+	}
+	WriteAudit(SanctionAuditOf(r, sanctionModel, sanctionID))
+	return sanctionID, nil
+}
+
+//SanctionAuditOf : the audit entry of a created sanction
+func SanctionAuditOf(r *http.Request, sanctionModel models.Sanction, sanctionID string) models.Audit {
+	audit := AdminAuditOf(r, models.AUDITSANCTION, sanctionModel.DeviceID)
+	audit.After = map[string]interface{}{
+		"sanction_id":   sanctionID,
+		"sanction_type": sanctionModel.SanctionType,
+		"reason":        sanctionModel.Reason,
+		"expired_time":  sanctionModel.ExpiredTime,
+	}
+	return audit
+}
+
+//AdminLiftSanctionEndPoint : Lift an active sanction
+func AdminLiftSanctionEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminLiftSanction
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	before, err := sanctionDAO.Lift(reqPayload.SanctionID, AdminOf(r).Name)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid sanction id"})
+		return
+	}
+	audit := AdminAuditOf(r, models.AUDITLIFTSANCTION, reqPayload.SanctionID)
+	audit.Before = map[string]interface{}{"device_id": before.DeviceID, "lifted_by": before.LiftedBy}
+	audit.After = map[string]interface{}{"device_id": before.DeviceID, "lifted_by": AdminOf(r).Name}
+	WriteAudit(audit)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+}
+
+//AdminSearchAuditEndPoint : Search the audit log by actor, action, target, request or date
+func AdminSearchAuditEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminSearchAudit
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	var from, to time.Time
+	var err error
+	if len(reqPayload.From) > 0 {
+		if from, err = time.Parse(time.RFC3339, reqPayload.From); err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid from date"})
+			return
+		}
+	}
+	if len(reqPayload.To) > 0 {
+		if to, err = time.Parse(time.RFC3339, reqPayload.To); err != nil {
+			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid to date"})
+			return
+		}
+	}
+	audits, err := auditDAO.Search(reqPayload.ActorID, reqPayload.Action, reqPayload.TargetID, reqPayload.RequestID,
+		from, to, reqPayload.Skip, AdminLimitOf(reqPayload.Limit))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if audits == nil {
+		audits = []models.Audit{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: audits})
+}
+
+//AdminListSanctionsEndPoint : List the sanctions of a player
+func AdminListSanctionsEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	var reqPayload payload.ReqMatchResult
+	var reqPayload payload.ReqAdminListSanctions
 	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-
-	matchObjID, err := helper.HexToObjID(reqPayload.MatchID)
+	var sanctions []models.Sanction
+	var err error
+	if reqPayload.ActiveOnly {
+		sanctions, err = sanctionDAO.FindActiveOf(reqPayload.DeviceID)
+	} else {
+		sanctions, err = sanctionDAO.FindOf(reqPayload.DeviceID, AdminSearchLimit)
+	}
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
+	if sanctions == nil {
+		sanctions = []models.Sanction{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: sanctions})
+}
 
-	match, err := matchDAO.FindByID(reqPayload.MatchID)
+//AdminCreateSeasonEndPoint : Schedule a season, it cannot overlap another one
+func AdminCreateSeasonEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminCreateSeason
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	startTime, err := time.Parse(time.RFC3339, reqPayload.StartTime)
 	if err != nil {
-		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid match id"})
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid start time"})
 		return
 	}
-
-	if match.MatchStatus == models.END || match.MatchStatus == models.ERR || match.MatchStatus == models.INV {
-		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
+	endTime, err := time.Parse(time.RFC3339, reqPayload.EndTime)
+	if err != nil || !endTime.After(startTime) {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid end time"})
+		return
+	}
+	if len(reqPayload.SeasonID) == 0 {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid season id"})
+		return
+	}
+	exist, err := seasonDAO.ExistOverlap(reqPayload.SeasonID, startTime, endTime)
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	if exist {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Season overlaps another season"})
+		return
+	}
+	var seasonModel = models.Season{
+		SeasonID:  reqPayload.SeasonID,
+		Name:      reqPayload.Name,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+	if err := seasonDAO.Insert(seasonModel); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
+	audit := AdminAuditOf(r, models.AUDITCREATESEASON, seasonModel.SeasonID)
+	audit.After = map[string]interface{}{
+		"name":       seasonModel.Name,
+		"start_time": seasonModel.StartTime,
+		"end_time":   seasonModel.EndTime,
+	}
+	WriteAudit(audit)
+	log.Printf("Admin %s created season %s", AdminOf(r).Name, seasonModel.SeasonID)
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
+}
 
-	var matchModel = models.Match{
-		ID: *matchObjID,
+//AdminSearchReportsEndPoint : The moderation queue, the oldest reports first
+func AdminSearchReportsEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminSearchReports
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	reports, err := reportDAO.Search(reqPayload.ReportedID, reqPayload.Category, reqPayload.ReportStatus,
+		reqPayload.Skip, AdminLimitOf(reqPayload.Limit))
+	if err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
 	}
+	if reports == nil {
+		reports = []models.Report{}
+	}
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: reports})
+}
 
-	if reqPayload.Winner {
-		if len(match.WinnerID) > 0 {
-			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
+//AdminGetReportEndPoint : A report with the move log of its match and the record of the reported player
+func AdminGetReportEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminReport
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	report, err := reportDAO.FindByID(reqPayload.ReportID)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid report id"})
+		return
+	}
+	resReport := payload.ResAdminReport{
+		Report: report,
+		Moves:  []payload.ResMove{},
+	}
+	if len(report.MatchID) > 0 {
+		match, err := matchDAO.FindByID(report.MatchID)
+		if err != nil {
+			RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 			return
 		}
-		matchModel.WinnerID = reqPayload.DeviceID
-	} else {
-		if len(match.LoserID) > 0 {
-			RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request"})
-			return
+		for _, mv := range match.Moves {
+			resReport.Moves = append(resReport.Moves, ToResMove(mv))
 		}
-		matchModel.LoserID = reqPayload.DeviceID
+		match.Moves = nil
+		match.WebRTCMessages = nil
+		resReport.Match = match
 	}
-
-	if err := matchDAO.Upsert(matchModel); err != nil {
+	if resReport.OpenReports, err = reportDAO.CountOpenOf(report.ReportedID); err != nil {
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	sanctions, err := sanctionDAO.FindOf(report.ReportedID, AdminSearchLimit)
+	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
+	if sanctions == nil {
+		sanctions = []models.Sanction{}
+	}
+	resReport.Sanctions = sanctions
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: resReport})
+}
 
+//AdminDismissReportEndPoint : Close an open report without a sanction
+func AdminDismissReportEndPoint(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var reqPayload payload.ReqAdminDismissReport
+	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
+		return
+	}
+	if _, err := reportDAO.FindByID(reqPayload.ReportID); err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid report id"})
+		return
+	}
+	if err := reportDAO.Handle(reqPayload.ReportID, models.DISMISSEDREPORT, AdminOf(r).Name, reqPayload.Note, ""); err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Report is handled"})
+			return
+		}
+		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		return
+	}
+	WriteAudit(ReportAuditOf(r, reqPayload.ReportID, models.DISMISSEDREPORT, reqPayload.Note, ""))
 	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: "Success"})
 }
 
-//GetPlayerRankEndPoint :
-func GetPlayerRankEndPoint(w http.ResponseWriter, r *http.Request) {
+//AdminEscalateReportEndPoint : Sanction the reported player and close the report
+func AdminEscalateReportEndPoint(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
-	var reqPayload payload.ReqGetRank
+	var reqPayload payload.ReqAdminEscalateReport
 	if err := helper.DecodeReqBody(r.Body, &reqPayload); err != nil {
 		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid request payload"})
 		return
 	}
-	topRank, err := statusDAO.FindTopRank()
+	report, err := reportDAO.FindByID(reqPayload.ReportID)
 	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: "Invalid report id"})
+		return
+	}
+	if report.ReportStatus != models.OPENREPORT {
+		RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Report is handled"})
 		return
 	}
-	currentRank, err := statusDAO.CalculateRankOf(reqPayload.DeviceID)
+	reason := fmt.Sprintf("%s report %s", report.Category, report.ID.Hex())
+	if len(reqPayload.Note) > 0 {
+		reason += ": " + reqPayload.Note
+	}
+	targetID, err := SanctionTargetOf(report.ReportedID)
 	if err != nil {
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
-	lastestMatches, err := matchDAO.FindLastestMatchesOf(reqPayload.DeviceID, reqPayload.MatchLimit)
+	sanctionModel, err := NewSanction(r, targetID, reqPayload.SanctionType, reason, reqPayload.DurationHours)
+	if err != nil {
+		RespondWithError(w, http.StatusBadRequest, payload.ResResult{Result: err.Error()})
+		return
+	}
+	sanctionID, err := dao.EscalateReport(reqPayload.ReportID, AdminOf(r).Name, reqPayload.Note, sanctionModel)
 	if err != nil {
+		if err.Error() == "NotFound" {
+			RespondWithError(w, http.StatusConflict, payload.ResResult{Result: "Report is handled"})
+			return
+		}
 		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
 		return
 	}
+	WriteAudit(SanctionAuditOf(r, sanctionModel, sanctionID))
+	WriteAudit(ReportAuditOf(r, reqPayload.ReportID, models.ESCALATEDREPORT, reqPayload.Note, sanctionID))
+	RespondWithJSON(w, http.StatusOK, payload.ResResult{Result: sanctionID})
+}
 
-	resPayload := payload.ResGetRank{}
-	resPayload.TopRankPlayerID = topRank.DeviceID
-	resPayload.TopRankPlayerName = topRank.PlayerName
-	resPayload.TopRankPlayerNation = topRank.PlayerNation
-	resPayload.YourCurrentRank = currentRank
-	resPayload.LastestMatches = []payload.ResGetRankMatch{}
+//AdminLimitOf : the page size of admin searches
+func AdminLimitOf(limit int64) int64 {
+	if limit <= 0 || limit > AdminSearchLimit {
+		return AdminSearchLimit
+	}
+	return limit
+}
 
-	var enemyIDs []string
-	for _, match := range lastestMatches {
-		resMatch := payload.ResGetRankMatch{}
-		resMatch.MatchID = match.ID.Hex()
-		resMatch.MatchDate = match.CreatedTime.Format(time.RFC3339)
-		if resMatch.EnemyID = match.Device1ID; match.Device1ID == reqPayload.DeviceID {
-			resMatch.EnemyID = match.Device2ID
-		}
-		resMatch.Win = match.WinnerID == reqPayload.DeviceID
+//DeviceAuditOf : an audit entry of an action by the device of the request
+func DeviceAuditOf(r *http.Request, action string, targetID string) models.Audit {
+	return models.Audit{
+		ActorType: models.ACTORDEVICE,
+		ActorID:   DeviceIDOf(r),
+		Action:    action,
+		TargetID:  targetID,
+		RequestID: RequestIDOf(r),
+	}
+}
 
-		enemyIDs = append(enemyIDs, resMatch.EnemyID)
-		resPayload.LastestMatches = append(resPayload.LastestMatches, resMatch)
+//AdminAuditOf : an audit entry of an action by the admin of the request
+func AdminAuditOf(r *http.Request, action string, targetID string) models.Audit {
+	return models.Audit{
+		ActorType: models.ACTORADMIN,
+		ActorID:   AdminOf(r).Name,
+		Action:    action,
+		TargetID:  targetID,
+		RequestID: RequestIDOf(r),
 	}
+}
 
-	enemyPlayers, err := statusDAO.FindByIDs(enemyIDs)
-	if err != nil {
-		RespondWithError(w, http.StatusInternalServerError, payload.ResResult{Result: err.Error()})
-		return
+//ReportAuditOf : an audit entry of an open report handled by the admin of the request
+func ReportAuditOf(r *http.Request, reportID string, status string, note string, sanctionID string) models.Audit {
+	audit := AdminAuditOf(r, models.AUDITHANDLEREPORT, reportID)
+	audit.Before = map[string]interface{}{"report_status": models.OPENREPORT}
+	audit.After = map[string]interface{}{
+		"report_status": status,
+		"handled_by":    AdminOf(r).Name,
 	}
-	// for idx, enemy := range enemyPlayers {
-	// 	resPayload.LastestMatches[idx].EnemyName = enemy.PlayerName
-	// 	resPayload.LastestMatches[idx].EnemyNation = enemy.PlayerNation
-	// }
-	for idx, rankMatch := range resPayload.LastestMatches {
-		enemyID := rankMatch.EnemyID
-		for _, enemy := range enemyPlayers {
-			if enemyID == enemy.DeviceID {
-				resPayload.LastestMatches[idx].EnemyName = enemy.PlayerName
-				resPayload.LastestMatches[idx].EnemyNation = enemy.PlayerNation
-				break
-			}
-		}
+	if len(note) > 0 {
+		audit.After["note"] = note
+	}
+	if len(sanctionID) > 0 {
+		audit.After["sanction_id"] = sanctionID
+	}
+	return audit
+}
+
+//WriteAudit : the operation is already done, a failed audit write is only logged.
+func WriteAudit(audit models.Audit) {
+	if err := auditDAO.Insert(audit); err != nil {
+		log.Printf("Audit %s of %s failed: %v", audit.Action, audit.TargetID, err)
 	}
+}
 
-	RespondWithJSON(w, http.StatusOK, resPayload)
+//StatusFields : the audited fields of a player status
+func StatusFields(stt models.Status) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if len(stt.PlayerName) > 0 {
+		fields["player_name"] = stt.PlayerName
+	}
+	if len(stt.PlayerStatus) > 0 {
+		fields["player_status"] = stt.PlayerStatus
+	}
+	if len(stt.PlayerNation) > 0 {
+		fields["player_nation"] = stt.PlayerNation
+	}
+	if len(stt.GameMode) > 0 {
+		fields["game_mode"] = stt.GameMode
+	}
+	return fields
 }
 
 //GetWelcomeEndpoint :
@@ -384,6 +2781,16 @@ func RespondWithError(w http.ResponseWriter, code int, payload interface{}) {
 	RespondWithJSON(w, code, payload)
 }
 
+//RespondWithNameError : a structured error of a rejected player name
+func RespondWithNameError(w http.ResponseWriter, code int, nameErr *helper.NameError) {
+	RespondWithError(w, code, payload.ResFieldError{
+		Result: "Invalid player name",
+		Field:  "player_name",
+		Code:   nameErr.Code,
+		Detail: nameErr.Detail,
+	})
+}
+
 //RespondWithJSON :
 func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
@@ -392,23 +2799,298 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
-//AuthMiddleware :
+//RequestIDMiddleware : keep the request id of the caller or generate one, it is echoed in the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("x-request-id")
+		if len(requestID) == 0 || len(requestID) > 64 {
+			requestID = primitive.NewObjectID().Hex()
+		}
+		w.Header().Set("x-request-id", requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+//RequestIDOf : the request id of a request.
+func RequestIDOf(r *http.Request) string {
+	requestID, _ := r.Context().Value(requestIDKey).(string)
+	return requestID
+}
+
+//RateLimitMiddleware : limit the requests of a route group per device and per IP.
+//It runs before the authentication, so the device is read from the token without a database call.
+func RateLimitMiddleware(group string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit, exist := cfg.RateLimits[group]
+			if !exist {
+				next.ServeHTTP(w, r)
+				return
+			}
+			now := time.Now()
+			deviceID := DeviceIDOf(r)
+			if len(deviceID) == 0 {
+				if claims, err := helper.ParseDeviceToken(cfg.TokenSecret, r.Header.Get("x-earthshaker-token"), now); err == nil {
+					deviceID = claims.Subject
+				}
+			}
+			if len(deviceID) > 0 && limit.DeviceRate > 0 {
+				if !TakeRateToken(w, group+":device:"+deviceID, limit.DeviceRate, limit.DeviceBurst, now) {
+					return
+				}
+			}
+			if limit.IPRate > 0 {
+				if !TakeRateToken(w, group+":ip:"+ClientIPOf(r), limit.IPRate, limit.IPBurst, now) {
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+//TakeRateToken : respond 429 if the bucket is empty, a failing store lets the request through.
+func TakeRateToken(w http.ResponseWriter, key string, rate float64, burst int64, now time.Time) bool {
+	ok, retryAfter, err := rateStore.Take(key, rate, burst, now)
+	if err != nil {
+		log.Printf("Rate limit of %s failed: %v", key, err)
+		return true
+	}
+	if !ok {
+		seconds := int64(math.Ceil(retryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+//ClientIPOf : the IP of the client, behind trusted proxies the forwarded one appended by the first of them.
+//Proxies append to X-Forwarded-For, so it is counted from the right, the entries before it are sent by the client.
+func ClientIPOf(r *http.Request) string {
+	if cfg.TrustForwardedFor {
+		var forwarded []string
+		for _, header := range r.Header["X-Forwarded-For"] {
+			forwarded = append(forwarded, strings.Split(header, ",")...)
+		}
+		hops := cfg.ForwardedForHops
+		if hops < 1 {
+			hops = 1
+		}
+		if len(forwarded) >= hops {
+			return strings.TrimSpace(forwarded[len(forwarded)-hops])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+//APIKeyMiddleware : any currently valid app key, used to register devices.
+func APIKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("x-earthshaker-token")
+		keyID, valid := keySet.Match(token, time.Now())
+		if !valid {
+			if len(keyID) > 0 {
+				log.Printf("Rejected app key %s: %s %s", keyID, r.Method, r.URL.Path)
+			}
+			keyMetrics.Add("rejected", 1)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		log.Printf("App key %s: %s %s", keyID, r.Method, r.URL.Path)
+		keyMetrics.Add(keyID, 1)
+		next.ServeHTTP(w, r)
+	})
+}
+
+//LoadAPIKeys : load the app keys from config and from the keys collection.
+func LoadAPIKeys() error {
+	var keys []models.APIKey
+	if len(cfg.APIKey) > 0 {
+		keys = append(keys, models.APIKey{KeyID: "default", Key: cfg.APIKey})
+	}
+	for _, key := range cfg.APIKeys {
+		keys = append(keys, models.APIKey{
+			KeyID:     key.ID,
+			Key:       key.Key,
+			NotBefore: key.NotBefore,
+			NotAfter:  key.NotAfter,
+			Revoked:   key.Revoked,
+		})
+	}
+	storedKeys, err := keyDAO.FindAll()
+	if err != nil {
+		return err
+	}
+	keys = append(keys, storedKeys...)
+	keySet.Replace(keys)
+	return nil
+}
+
+//AuthMiddleware : the device token, the device id of the request comes from it.
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("x-earthshaker-token")
-		if token == cfg.APIKey {
-			next.ServeHTTP(w, r)
-		} else {
+		claims, err := helper.ParseDeviceToken(cfg.TokenSecret, token, time.Now())
+		if err != nil {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		device, err := deviceDAO.FindByID(claims.Subject)
+		if err != nil && err != mongo.ErrNoDocuments {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err != nil || device.TokenGeneration != claims.Generation {
 			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		playerID, err := PlayerIDOfDevice(claims.Subject)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sanctions, err := sanctionDAO.FindActiveOfAny([]string{claims.Subject, playerID})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if sanction := helper.BlockingSanction(sanctions, false); sanction != nil {
+			http.Error(w, helper.SanctionMessage(sanction), http.StatusForbidden)
+			return
 		}
+		ctx := context.WithValue(r.Context(), deviceIDKey, claims.Subject)
+		ctx = context.WithValue(ctx, playerIDKey, playerID)
+		ctx = context.WithValue(ctx, tokenGenerationKey, claims.Generation)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-//ContentTypeMiddleware :
+//DeviceIDOf : the device id of an authenticated request.
+func DeviceIDOf(r *http.Request) string {
+	deviceID, _ := r.Context().Value(deviceIDKey).(string)
+	return deviceID
+}
+
+//TokenGenerationOf : the token generation of the device of an authenticated request.
+func TokenGenerationOf(r *http.Request) int64 {
+	generation, _ := r.Context().Value(tokenGenerationKey).(int64)
+	return generation
+}
+
+//PlayerIDOf : the player id of an authenticated request, the player data is kept under it.
+func PlayerIDOf(r *http.Request) string {
+	playerID, _ := r.Context().Value(playerIDKey).(string)
+	return playerID
+}
+
+//PlayerIDOfDevice : the player id of the account of a device, the device id if the device has no account yet.
+func PlayerIDOfDevice(deviceID string) (string, error) {
+	acc, err := accountDAO.FindByDevice(deviceID)
+	if err != nil {
+		if err.Error() == "NotFound" {
+			return deviceID, nil
+		}
+		return "", err
+	}
+	return acc.PlayerID, nil
+}
+
+//SignatureMiddleware : verify the request signature of the device, reject stale or replayed requests.
+func SignatureMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature := r.Header.Get("x-earthshaker-signature")
+		if len(signature) == 0 && !cfg.RequireSignature {
+			next.ServeHTTP(w, r)
+			return
+		}
+		timestamp := r.Header.Get("x-earthshaker-timestamp")
+		nonce := r.Header.Get("x-earthshaker-nonce")
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || len(nonce) == 0 {
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		now := time.Now()
+		skew := now.Sub(time.Unix(unix, 0))
+		if skew < 0 {
+			skew = -skew
+		}
+		if skew > time.Duration(cfg.SignatureSkewSeconds)*time.Second {
+			http.Error(w, "Stale request", http.StatusUnauthorized)
+			return
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxSignedBodyBytes))
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		deviceID := DeviceIDOf(r)
+		key := helper.DeviceSigningKey(cfg.TokenSecret, deviceID, TokenGenerationOf(r))
+		canonical := helper.CanonicalRequest(r.Method, r.URL.Path, timestamp, nonce, body)
+		if !hmac.Equal([]byte(signature), []byte(helper.SignRequest(key, canonical))) {
+			http.Error(w, "Invalid signature", http.StatusUnauthorized)
+			return
+		}
+		if !nonceCache.Use(deviceID+":"+nonce, now) {
+			http.Error(w, "Replayed request", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//AdminAuthMiddleware : the admin key, the admin of the request comes from it.
+func AdminAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("x-earthshaker-admin")
+		for _, admin := range cfg.Admins {
+			if len(key) > 0 && hmac.Equal([]byte(admin.Key), []byte(key)) {
+				ctx := context.WithValue(r.Context(), adminKey, admin)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+		}
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	})
+}
+
+//RequireRole : only admins with the role can call the handler.
+func RequireRole(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, adminRole := range AdminOf(r).Roles {
+			if adminRole == role {
+				next(w, r)
+				return
+			}
+		}
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	}
+}
+
+//AdminOf : the admin of an authenticated admin request.
+func AdminOf(r *http.Request) config.Admin {
+	admin, _ := r.Context().Value(adminKey).(config.Admin)
+	return admin
+}
+
+//ContentTypeMiddleware : request bodies must be JSON, GET requests have none
 func ContentTypeMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("Content-Type")
-		if token == "application/json" {
+		if token == "application/json" || r.Method == http.MethodGet {
 			next.ServeHTTP(w, r)
 		} else {
 			http.Error(w, "Content-Type header is not application/json", http.StatusUnsupportedMediaType)
@@ -419,31 +3101,152 @@ func ContentTypeMiddleware(next http.Handler) http.Handler {
 func init() {
 	log.SetOutput(os.Stdout)
 	cfg.Read()
+	nonceCache = helper.NewNonceCache(2 * time.Duration(cfg.SignatureSkewSeconds) * time.Second)
 	dao.Setup(cfg.Database)
 	dao.Connect(cfg.AtlasURI)
 
+	keyDAO.Setup()
+	deviceDAO.Setup()
 	statusDAO.Setup()
 	matchDAO.Setup()
+	replayDAO.Setup()
+	sanctionDAO.Setup()
+	auditDAO.Setup()
+	reportDAO.Setup()
+	seasonDAO.Setup()
+	seasonRecordDAO.Setup()
+	snapshotDAO.Setup()
+	achievementDAO.Setup()
+	relationDAO.Setup()
+	accountDAO.Setup()
+	linkCodeDAO.Setup()
+	profileDAO.Setup()
+
+	if cfg.RateLimitStore == "mongo" {
+		rateLimitDAO.Setup()
+		rateStore = &rateLimitDAO
+	} else {
+		rateStore = helper.NewMemoryRateStore()
+	}
+
+	if err := LoadAPIKeys(); err != nil {
+		log.Fatal(err)
+	}
+	if err := statusDAO.BackfillMMR(); err != nil {
+		log.Fatal(err)
+	}
+	if err := statusDAO.BackfillNameKey(); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.UniqueNames {
+		if err := statusDAO.EnsureUniqueNames(); err != nil {
+			log.Println("unique names are not indexed:", err)
+		}
+	}
 }
 
 func main() {
 	defer dao.Disconnect()
 
+	go func() {
+		for {
+			time.Sleep(KeyRefreshInterval)
+			if err := LoadAPIKeys(); err != nil {
+				log.Println(err)
+			}
+		}
+	}()
+
 	r := mux.NewRouter()
+	r.Use(RequestIDMiddleware)
 	r.HandleFunc("/earthshaker/v1/welcome", GetWelcomeEndpoint).Methods("GET")
+	device := r.PathPrefix("/earthshaker/v1/device").Subrouter()
+	device.Use(RateLimitMiddleware("Device"))
+	device.Use(APIKeyMiddleware)
+	device.Use(ContentTypeMiddleware)
+	device.HandleFunc("/register", RegisterDeviceEndPoint).Methods("POST")
+	device.HandleFunc("/auth", AuthenticateDeviceEndPoint).Methods("POST")
+	device.HandleFunc("/claim", ClaimDeviceEndPoint).Methods("POST")
+	poll := r.PathPrefix("/earthshaker/v1").Subrouter()
+	poll.Use(RateLimitMiddleware("Poll"))
+	poll.Use(AuthMiddleware)
+	poll.Use(SignatureMiddleware)
+	poll.Use(ContentTypeMiddleware)
+	poll.HandleFunc("/match/ready", GetMatchReadyEndPoint).Methods("POST")
+	poll.HandleFunc("/match/webrtc/receive", ReceiveWebRTCMsgEndPoint).Methods("POST")
+	poll.HandleFunc("/match/sync/receive", ReceiveMoveEndPoint).Methods("POST")
+	poll.HandleFunc("/spectate/matches", GetLiveMatchesEndPoint).Methods("GET")
+	poll.HandleFunc("/spectate/sync/receive", SpectateMoveEndPoint).Methods("POST")
 	api := r.PathPrefix("/earthshaker/v1").Subrouter()
+	api.Use(RateLimitMiddleware("API"))
 	api.Use(AuthMiddleware)
+	api.Use(SignatureMiddleware)
 	api.Use(ContentTypeMiddleware)
+	api.HandleFunc("/token/refresh", RefreshDeviceTokenEndPoint).Methods("POST")
+	api.HandleFunc("/token/secret", RotateDeviceSecretEndPoint).Methods("POST")
+	api.HandleFunc("/account", GetAccountEndPoint).Methods("GET")
+	api.HandleFunc("/account/link/code", CreateLinkCodeEndPoint).Methods("POST")
+	api.HandleFunc("/account/link", LinkDeviceEndPoint).Methods("POST")
 	api.HandleFunc("/player/status", GetOnlinePlayersEndpoint).Methods("GET")
 	api.HandleFunc("/player/status/upsert", UpsertStatusEndPoint).Methods("POST")
 	api.HandleFunc("/player/rank", GetPlayerRankEndPoint).Methods("POST")
+	api.HandleFunc("/profile", GetProfileEndPoint).Methods("POST")
+	api.HandleFunc("/profile/upsert", UpsertProfileEndPoint).Methods("POST")
+	api.HandleFunc("/profile/delete", DeleteProfileEndPoint).Methods("POST")
+	api.HandleFunc("/player/report", ReportPlayerEndPoint).Methods("POST")
+	api.HandleFunc("/player/stats", GetPlayerStatsEndPoint).Methods("POST")
+	api.HandleFunc("/player/history", GetMatchHistoryEndPoint).Methods("POST")
+	api.HandleFunc("/player/achievements", GetAchievementsEndPoint).Methods("POST")
+	api.HandleFunc("/friend/list", GetFriendsEndPoint).Methods("GET")
+	api.HandleFunc("/friend/feed", GetFriendFeedEndPoint).Methods("POST")
+	api.HandleFunc("/friend/request", RequestFriendEndPoint).Methods("POST")
+	api.HandleFunc("/friend/accept", AcceptFriendEndPoint).Methods("POST")
+	api.HandleFunc("/friend/decline", DeclineFriendEndPoint).Methods("POST")
+	api.HandleFunc("/friend/remove", RemoveFriendEndPoint).Methods("POST")
+	api.HandleFunc("/friend/block", BlockPlayerEndPoint).Methods("POST")
+	api.HandleFunc("/friend/unblock", UnblockPlayerEndPoint).Methods("POST")
+	api.HandleFunc("/leaderboard", GetLeaderboardEndPoint).Methods("POST")
+	api.HandleFunc("/leaderboard/around", GetLeaderboardAroundEndPoint).Methods("POST")
+	api.HandleFunc("/leaderboard/nations", GetNationStatsEndPoint).Methods("GET")
+	api.HandleFunc("/seasons", GetSeasonsEndPoint).Methods("GET")
+	api.HandleFunc("/player/seasons", GetSeasonRecordsEndPoint).Methods("POST")
 	api.HandleFunc("/match/info", GetMatchInfoEndPoint).Methods("POST")
-	api.HandleFunc("/match/ready", GetMatchReadyEndPoint).Methods("POST")
 	api.HandleFunc("/match/info/update", UpdateMatchResultEndPoint).Methods("PUT")
-	// r.HandleFunc("/match/webrtc/send", SendWebRTCMsgEndPoint).Methods("POST")
-	// r.HandleFunc("/match/webrtc/receive", ReceiveWebRTCMsgEndPoint).Methods("POST")
+	api.HandleFunc("/match/resume", ResumeMatchEndPoint).Methods("POST")
+	api.HandleFunc("/match/replay", GetMatchReplayEndPoint).Methods("POST")
+	api.HandleFunc("/match/replay/export", ExportMatchReplayEndPoint).Methods("POST")
+	api.HandleFunc("/match/webrtc/send", SendWebRTCMsgEndPoint).Methods("POST")
+	api.HandleFunc("/match/webrtc/ice", GetICEServersEndPoint).Methods("POST")
 	api.HandleFunc("/match/sync/send", SendMoveEndPoint).Methods("POST")
-	api.HandleFunc("/match/sync/receive", ReceiveMoveEndPoint).Methods("POST")
+
+	if cfg.EnableDebug {
+		debug := r.PathPrefix("/earthshaker/v1/debug").Subrouter()
+		debug.Use(RateLimitMiddleware("API"))
+		debug.Use(AuthMiddleware)
+		debug.Use(SignatureMiddleware)
+		debug.HandleFunc("/match/replay/import", ImportMatchReplayEndPoint).Methods("POST")
+	}
+
+	admin := r.PathPrefix("/earthshaker/admin/v1").Subrouter()
+	admin.Use(RateLimitMiddleware("Admin"))
+	admin.Use(AdminAuthMiddleware)
+	admin.Use(ContentTypeMiddleware)
+	admin.HandleFunc("/metrics", RequireRole(models.ROLEVIEWER, expvar.Handler().ServeHTTP)).Methods("GET")
+	admin.HandleFunc("/player/search", RequireRole(models.ROLEVIEWER, AdminSearchPlayersEndPoint)).Methods("POST")
+	admin.HandleFunc("/match/search", RequireRole(models.ROLEVIEWER, AdminSearchMatchesEndPoint)).Methods("POST")
+	admin.HandleFunc("/match/status", RequireRole(models.ROLEOPERATOR, AdminUpdateMatchStatusEndPoint)).Methods("PUT")
+	admin.HandleFunc("/match/resolve", RequireRole(models.ROLEOPERATOR, AdminResolveMatchEndPoint)).Methods("POST")
+	admin.HandleFunc("/player/mmr", RequireRole(models.ROLEOPERATOR, AdminAdjustMMREndPoint)).Methods("PUT")
+	admin.HandleFunc("/device/claim", RequireRole(models.ROLEOPERATOR, AdminCreateDeviceClaimEndPoint)).Methods("POST")
+	admin.HandleFunc("/sanction/create", RequireRole(models.ROLEMODERATOR, AdminCreateSanctionEndPoint)).Methods("POST")
+	admin.HandleFunc("/sanction/lift", RequireRole(models.ROLEMODERATOR, AdminLiftSanctionEndPoint)).Methods("PUT")
+	admin.HandleFunc("/sanction/list", RequireRole(models.ROLEVIEWER, AdminListSanctionsEndPoint)).Methods("POST")
+	admin.HandleFunc("/season/create", RequireRole(models.ROLEOPERATOR, AdminCreateSeasonEndPoint)).Methods("POST")
+	admin.HandleFunc("/report/search", RequireRole(models.ROLEVIEWER, AdminSearchReportsEndPoint)).Methods("POST")
+	admin.HandleFunc("/report/detail", RequireRole(models.ROLEVIEWER, AdminGetReportEndPoint)).Methods("POST")
+	admin.HandleFunc("/report/dismiss", RequireRole(models.ROLEMODERATOR, AdminDismissReportEndPoint)).Methods("PUT")
+	admin.HandleFunc("/report/escalate", RequireRole(models.ROLEMODERATOR, AdminEscalateReportEndPoint)).Methods("PUT")
+	admin.HandleFunc("/audit/search", RequireRole(models.ROLEVIEWER, AdminSearchAuditEndPoint)).Methods("POST")
 
 	log.Println("Try starting server at port 6526")
 	err := http.ListenAndServe(":6526", r)
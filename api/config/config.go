@@ -2,6 +2,7 @@ package config
 
 import (
 	"log"
+	"time"
 
 	"github.com/BurntSushi/toml"
 )
@@ -11,6 +12,90 @@ type Config struct {
 	AtlasURI string
 	Database string
 	APIKey   string
+
+	ReconnectGraceSeconds int64
+	EnableDebug           bool
+	DefaultGameMode       string
+	GameModes             map[string]GameMode
+	SpectatorDelaySeconds int64
+	TURNSecret            string
+	TURNTTLSeconds        int64
+	ICEServers            []ICEServer
+	TokenSecret           string
+	TokenTTLHours         int64
+	RequireSignature      bool
+	SignatureSkewSeconds  int64
+	APIKeys               []APIKey
+	Admins                []Admin
+	RateLimitStore        string
+	TrustForwardedFor     bool
+	ForwardedForHops      int
+	RateLimits            map[string]RateLimit
+	NameMinLength         int
+	NameMaxLength         int
+	NameDenylist          []string
+	UniqueNames           bool
+	SeasonBaseMMR         int64
+	SeasonResetFactor     float64
+	RankSnapshotMinutes   int64
+	Achievements          []Achievement
+	LinkCodeTTLMinutes    int64
+	Avatars               []string
+	Titles                []Title
+	ProfileBioMaxLength   int
+}
+
+//GameMode : time controls of a game mode in seconds, zero means no limit
+type GameMode struct {
+	TurnSeconds  int64
+	MatchSeconds int64
+	Ranked       bool
+}
+
+//APIKey : an app key with its validity window, zero times mean no bound
+type APIKey struct {
+	ID        string
+	Key       string
+	NotBefore time.Time
+	NotAfter  time.Time
+	Revoked   bool
+}
+
+//Admin : an admin key and the roles it grants
+type Admin struct {
+	Name  string
+	Key   string
+	Roles []string
+}
+
+//RateLimit : token buckets of a route group per device and per IP, rates are tokens per second, zero means no limit
+type RateLimit struct {
+	DeviceRate  float64
+	DeviceBurst int64
+	IPRate      float64
+	IPBurst     int64
+}
+
+//Achievement : an achievement granted once the metric of a player reaches the threshold
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	Metric      string
+	Threshold   int64
+}
+
+//Title : a title players can show on their profile, unlocked by an achievement if one is given
+type Title struct {
+	ID          string
+	Name        string
+	Achievement string
+}
+
+//ICEServer : a STUN server, or a TURN server when credentials are needed
+type ICEServer struct {
+	URLs []string
+	TURN bool
 }
 
 //Read config
@@ -19,3 +104,27 @@ func (c *Config) Read() {
 		log.Fatal(err)
 	}
 }
+
+//IsGameMode : check if the game mode is configured
+func (c *Config) IsGameMode(name string) bool {
+	_, exist := c.GameModes[name]
+	return exist
+}
+
+//GameModeOf : get the game mode by name, the default one if it is empty
+func (c *Config) GameModeOf(name string) GameMode {
+	if len(name) == 0 {
+		name = c.DefaultGameMode
+	}
+	return c.GameModes[name]
+}
+
+//TurnLimit : time limit of a turn, zero means no limit
+func (g GameMode) TurnLimit() time.Duration {
+	return time.Duration(g.TurnSeconds) * time.Second
+}
+
+//MatchLimit : time limit of a player in a match, zero means no limit
+func (g GameMode) MatchLimit() time.Duration {
+	return time.Duration(g.MatchSeconds) * time.Second
+}
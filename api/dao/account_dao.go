@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//AccountCollection : collection of the accounts of the players
+const AccountCollection = "player_account"
+
+//AccountDAO : the accounts the devices are linked to
+type AccountDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *AccountDAO) Setup() {
+	m.c = mgoDB.Collection(AccountCollection)
+	m.timeOut = 3 * time.Second
+}
+
+//FindByDevice : find the account a device is linked to.
+func (m *AccountDAO) FindByDevice(deviceID string) (models.Account, error) {
+	var acc models.Account
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOne(ctx, bson.M{"device_ids": deviceID}).Decode(&acc)
+	if err == mongo.ErrNoDocuments {
+		return acc, errors.New("NotFound")
+	}
+	return acc, err
+}
+
+//ExistPlayer : check if an account has the player id.
+func (m *AccountDAO) ExistPlayer(playerID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	count, err := m.c.CountDocuments(ctx, bson.M{"player_id": playerID})
+	return count > 0, err
+}
+
+//Create : create the account of a device whose player id is the device id, nothing if the device has one.
+func (m *AccountDAO) Create(deviceID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	now := time.Now()
+	_, err := m.c.UpdateOne(ctx, bson.M{"device_ids": deviceID}, bson.M{
+		"$setOnInsert": bson.M{
+			"player_id":    deviceID,
+			"device_ids":   []string{deviceID},
+			"updated_time": now,
+			"created_time": now,
+		},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+//FindDevicesIn : find the device ids of a collection keyed by device id, after a record if it is given,
+//used to create the accounts of the devices registered before accounts.
+func (m *AccountDAO) FindDevicesIn(collection string, after *models.Device, limit int64) ([]models.Device, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"_id": 1,
+	})
+	findOptions.SetProjection(bson.M{
+		"device_id": 1,
+	})
+	conditions := bson.M{}
+	if after != nil {
+		conditions["_id"] = bson.M{"$gt": after.ID}
+	}
+	cur, err := mgoDB.Collection(collection).Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Device
+	for cur.Next(ctx) {
+		var elem models.Device
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
@@ -0,0 +1,78 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//AchievementDAO : the achievements granted to the players
+type AchievementDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *AchievementDAO) Setup() {
+	m.c = mgoDB.Collection("player_achievement")
+	m.timeOut = 3 * time.Second
+	ensureIndex(m.c, mongo.IndexModel{
+		Keys:    bson.D{{Key: "device_id", Value: 1}, {Key: "achievement_id", Value: 1}},
+		Options: options.Index().SetName("device_achievement_unique").SetUnique(true),
+	})
+}
+
+//Grant : grant an achievement to a player, granting it again keeps the first grant.
+//The unique index makes a concurrent grant fail with a duplicate key, the first grant is kept then too.
+func (m *AchievementDAO) Grant(deviceID string, achievementID string, matchID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	_, err := m.c.UpdateOne(ctx, bson.M{
+		"device_id":      deviceID,
+		"achievement_id": achievementID,
+	}, bson.M{
+		"$setOnInsert": bson.M{
+			"match_id":     matchID,
+			"granted_time": time.Now(),
+		},
+	}, options.Update().SetUpsert(true))
+	if isDuplicateKey(err) {
+		return nil
+	}
+	return err
+}
+
+//FindOf : find the achievements of a player.
+func (m *AchievementDAO) FindOf(deviceID string) ([]models.Achievement, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetSort(bson.M{
+		"granted_time": 1,
+	})
+	cur, err := m.c.Find(ctx, bson.M{"device_id": deviceID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Achievement
+	for cur.Next(ctx) {
+		var elem models.Achievement
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
@@ -0,0 +1,91 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//AuditDAO : the audit log, entries are only appended.
+type AuditDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//AuditCollection : name
+const AuditCollection = "audit_log"
+
+//Setup : Set collection name
+func (m *AuditDAO) Setup() {
+	m.c = mgoDB.Collection(AuditCollection)
+	m.timeOut = 3 * time.Second
+}
+
+//Insert : append an entry.
+func (m *AuditDAO) Insert(adt models.Audit) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	adt.CreatedTime = time.Now()
+	_, err := m.c.InsertOne(ctx, adt)
+	return err
+}
+
+//Search : find entries by actor, action, target and request, created in [from, to), empty values match all.
+func (m *AuditDAO) Search(actorID string, action string, targetID string, requestID string, from time.Time, to time.Time, skip int64, limit int64) ([]models.Audit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetSkip(skip)
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"created_time": -1,
+	})
+	conditions := bson.M{}
+	if len(actorID) > 0 {
+		conditions["actor_id"] = actorID
+	}
+	if len(action) > 0 {
+		conditions["action"] = action
+	}
+	if len(targetID) > 0 {
+		conditions["target_id"] = targetID
+	}
+	if len(requestID) > 0 {
+		conditions["request_id"] = requestID
+	}
+	createdTime := bson.M{}
+	if !from.IsZero() {
+		createdTime["$gte"] = from
+	}
+	if !to.IsZero() {
+		createdTime["$lt"] = to
+	}
+	if len(createdTime) > 0 {
+		conditions["created_time"] = createdTime
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Audit
+	for cur.Next(ctx) {
+		var elem models.Audit
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
@@ -3,10 +3,12 @@ package dao
 import (
 	"context"
 	"earthshaker/api/models"
+	"errors"
 	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
@@ -18,6 +20,9 @@ var mgoDatabase string
 var mgoClient *mongo.Client
 var mgoDB *mongo.Database
 
+//AggregateTimeOut : time out of aggregations over a whole collection
+const AggregateTimeOut = 30 * time.Second
+
 //Setup : setup database name from config
 func Setup(database string) {
 	mgoDatabase = database
@@ -50,10 +55,20 @@ func Disconnect() {
 	mgoClient.Disconnect(ctx)
 }
 
-//CreateMatches :
-func CreateMatches(players *[]models.Status, matches *[]models.Match) error {
+//ensureIndex : create an index of a collection if it is missing, a failure is logged and the collection is used without it.
+func ensureIndex(c *mongo.Collection, model mongo.IndexModel) {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	if _, err := c.Indexes().CreateOne(ctx, model); err != nil {
+		log.Printf("Index of %s is not created: %v", c.Name(), err)
+	}
+}
+
+//CreateMatches : actor is the audit entry template of the caller.
+func CreateMatches(players *[]models.Status, matches *[]models.Match, actor models.Audit) error {
 	sttDAO := mgoDB.Collection("player_status")
 	mchDAO := mgoDB.Collection("match_info")
+	adtDAO := mgoDB.Collection(AuditCollection)
 	return mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
 		err := sctx.StartTransaction(options.Transaction().
 			SetReadConcern(readconcern.Snapshot()).
@@ -65,7 +80,12 @@ func CreateMatches(players *[]models.Status, matches *[]models.Match) error {
 
 		for i := 0; i < len(*players); i++ {
 			player := (*players)[i]
-			_, err := sttDAO.UpdateOne(sctx, bson.M{"device_id": player.DeviceID}, bson.M{"$set": player})
+			_, err := sttDAO.UpdateOne(sctx, bson.M{"device_id": player.DeviceID}, bson.M{
+				"$set": bson.M{
+					"player_status": player.PlayerStatus,
+					"updated_time":  time.Now(),
+				},
+			})
 			if err != nil {
 				sctx.AbortTransaction(sctx)
 				return err
@@ -75,10 +95,34 @@ func CreateMatches(players *[]models.Status, matches *[]models.Match) error {
 		for _, m := range *matches {
 			mches = append(mches, m)
 		}
-		_, err = mchDAO.InsertMany(sctx, mches)
-		if err != nil {
-			sctx.AbortTransaction(sctx)
-			return err
+		if len(mches) > 0 {
+			rs, err := mchDAO.InsertMany(sctx, mches)
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
+			var audits []interface{}
+			for i, id := range rs.InsertedIDs {
+				m := (*matches)[i]
+				audit := actor
+				audit.Action = models.AUDITCREATEMATCH
+				if objID, ok := id.(primitive.ObjectID); ok {
+					audit.TargetID = objID.Hex()
+				}
+				audit.After = map[string]interface{}{
+					"match_status": m.MatchStatus,
+					"game_mode":    m.GameMode,
+					"device1_id":   m.Device1ID,
+					"device2_id":   m.Device2ID,
+				}
+				audit.CreatedTime = time.Now()
+				audits = append(audits, audit)
+			}
+			_, err = adtDAO.InsertMany(sctx, audits)
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
 		}
 
 		err = sctx.CommitTransaction(sctx)
@@ -90,10 +134,11 @@ func CreateMatches(players *[]models.Status, matches *[]models.Match) error {
 	})
 }
 
-//VerifyAndUpdateMMR :
-func VerifyAndUpdateMMR(matches []models.Match, playerMMRs map[string]int64) error {
+//VerifyAndUpdateMMR : actor is the audit entry template of the caller.
+func VerifyAndUpdateMMR(matches []models.Match, playerMMRs map[string]int64, actor models.Audit) error {
 	statusDAO := mgoDB.Collection("player_status")
 	matchDAO := mgoDB.Collection("match_info")
+	auditDAO := mgoDB.Collection(AuditCollection)
 	return mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
 		err := sctx.StartTransaction(options.Transaction().
 			SetReadConcern(readconcern.Snapshot()).
@@ -102,20 +147,393 @@ func VerifyAndUpdateMMR(matches []models.Match, playerMMRs map[string]int64) err
 		if err != nil {
 			return err
 		}
+		var audits []interface{}
 		for _, match := range matches {
-			_, err := matchDAO.UpdateOne(sctx, bson.M{"_id": match.ID}, bson.M{"$set": match})
+			var before models.Match
+			err := matchDAO.FindOneAndUpdate(sctx, bson.M{"_id": match.ID}, bson.M{"$set": match}).Decode(&before)
 			if err != nil {
 				sctx.AbortTransaction(sctx)
 				return err
 			}
+			audit := actor
+			audit.Action = models.AUDITRESOLVEMATCH
+			audit.TargetID = match.ID.Hex()
+			audit.Before, audit.After = ChangedFields(resultFields(before), resultFields(match))
+			audit.CreatedTime = time.Now()
+			audits = append(audits, audit)
 		}
 
 		for k, v := range playerMMRs {
-			rs := statusDAO.FindOneAndUpdate(sctx, bson.M{"device_id": k}, bson.M{"$inc": bson.M{"player_mmr": v}})
-			if rs.Err() != nil {
+			var before models.Status
+			err := statusDAO.FindOneAndUpdate(sctx, bson.M{"device_id": k}, bson.M{"$inc": bson.M{"player_mmr": v}}).Decode(&before)
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
+			audit := actor
+			audit.Action = models.AUDITUPDATEMMR
+			audit.TargetID = k
+			audit.Before = map[string]interface{}{"player_mmr": before.PlayerMMR}
+			audit.After = map[string]interface{}{"player_mmr": before.PlayerMMR + v}
+			audit.CreatedTime = time.Now()
+			audits = append(audits, audit)
+		}
+
+		if len(audits) > 0 {
+			_, err = auditDAO.InsertMany(sctx, audits)
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
+		}
+
+		err = sctx.CommitTransaction(sctx)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		return nil
+	})
+}
+
+//ChangedFields : keep only the fields that differ between before and after.
+func ChangedFields(before map[string]interface{}, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for k, v := range after {
+		if before[k] != v {
+			changedBefore[k] = before[k]
+			changedAfter[k] = v
+		}
+	}
+	for k, v := range before {
+		if _, exist := after[k]; !exist {
+			changedBefore[k] = v
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+func resultFields(match models.Match) map[string]interface{} {
+	return map[string]interface{}{
+		"match_status": match.MatchStatus,
+		"winner_id":    match.WinnerID,
+		"loser_id":     match.LoserID,
+		"end_reason":   match.EndReason,
+	}
+}
+
+//ApplySeasonReset : move the mmr of the players by the reset of their records and mark the records,
+//with an audit entry per player in a transaction, so the reset is applied once and the mmr won in the meantime is kept.
+func ApplySeasonReset(records []models.SeasonRecord, actor models.Audit) error {
+	statusDAO := mgoDB.Collection("player_status")
+	recordDAO := mgoDB.Collection(SeasonRecordCollection)
+	auditDAO := mgoDB.Collection(AuditCollection)
+	return mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
+		err := sctx.StartTransaction(options.Transaction().
+			SetReadConcern(readconcern.Snapshot()).
+			SetWriteConcern(writeconcern.New(writeconcern.WMajority())),
+		)
+		if err != nil {
+			return err
+		}
+
+		var audits []interface{}
+		for _, record := range records {
+			rs, err := recordDAO.UpdateOne(sctx, bson.M{
+				"_id":        record.ID,
+				"reset_time": bson.M{"$exists": false},
+			}, bson.M{"$set": bson.M{"reset_time": time.Now()}})
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
+			if rs.MatchedCount == 0 {
+				continue
+			}
+			var before models.Status
+			err = statusDAO.FindOneAndUpdate(sctx, bson.M{"device_id": record.DeviceID}, bson.M{
+				"$inc": bson.M{"player_mmr": record.ResetMMR - record.FinalMMR},
+			}).Decode(&before)
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
+			audit := actor
+			audit.Action = models.AUDITUPDATEMMR
+			audit.TargetID = record.DeviceID
+			audit.Before = map[string]interface{}{"player_mmr": before.PlayerMMR}
+			audit.After = map[string]interface{}{"player_mmr": before.PlayerMMR + record.ResetMMR - record.FinalMMR}
+			audit.CreatedTime = time.Now()
+			audits = append(audits, audit)
+		}
+
+		if len(audits) > 0 {
+			_, err = auditDAO.InsertMany(sctx, audits)
+			if err != nil {
+				sctx.AbortTransaction(sctx)
+				return err
+			}
+		}
+
+		err = sctx.CommitTransaction(sctx)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		return nil
+	})
+}
+
+//AcceptFriend : turn the pending request of the requester into a friendship in both directions, in a transaction.
+func AcceptFriend(deviceID string, requesterID string) error {
+	relationDAO := mgoDB.Collection(RelationCollection)
+	return mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
+		err := sctx.StartTransaction(options.Transaction().
+			SetReadConcern(readconcern.Snapshot()).
+			SetWriteConcern(writeconcern.New(writeconcern.WMajority())),
+		)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		rs, err := relationDAO.UpdateOne(sctx, bson.M{
+			"device_id":       requesterID,
+			"target_id":       deviceID,
+			"relation_status": models.PENDINGFRIEND,
+		}, bson.M{"$set": bson.M{
+			"relation_status": models.FRIEND,
+			"updated_time":    now,
+		}})
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		if rs.MatchedCount == 0 {
+			sctx.AbortTransaction(sctx)
+			return errors.New("NotFound")
+		}
+		_, err = relationDAO.UpdateOne(sctx, bson.M{
+			"device_id": deviceID,
+			"target_id": requesterID,
+		}, bson.M{
+			"$set": bson.M{
+				"relation_status": models.FRIEND,
+				"updated_time":    now,
+			},
+			"$setOnInsert": bson.M{"created_time": now},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+
+		err = sctx.CommitTransaction(sctx)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		return nil
+	})
+}
+
+//BlockPlayer : end any friendship or request between the players and block the target, in a transaction,
+//a block of the target in the other direction is kept.
+func BlockPlayer(deviceID string, targetID string) error {
+	relationDAO := mgoDB.Collection(RelationCollection)
+	return mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
+		err := sctx.StartTransaction(options.Transaction().
+			SetReadConcern(readconcern.Snapshot()).
+			SetWriteConcern(writeconcern.New(writeconcern.WMajority())),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = relationDAO.DeleteMany(sctx, bson.M{"$or": []bson.M{
+			bson.M{"device_id": deviceID, "target_id": targetID, "relation_status": bson.M{"$ne": models.BLOCKED}},
+			bson.M{"device_id": targetID, "target_id": deviceID, "relation_status": bson.M{"$ne": models.BLOCKED}},
+		}})
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		now := time.Now()
+		_, err = relationDAO.UpdateOne(sctx, bson.M{
+			"device_id": deviceID,
+			"target_id": targetID,
+		}, bson.M{
+			"$set": bson.M{
+				"relation_status": models.BLOCKED,
+				"updated_time":    now,
+			},
+			"$setOnInsert": bson.M{"created_time": now},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+
+		err = sctx.CommitTransaction(sctx)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		return nil
+	})
+}
+
+//EscalateReport : claim an open report as escalated and insert its sanction, in a transaction. It returns the sanction id.
+func EscalateReport(reportID string, handledBy string, note string, snt models.Sanction) (string, error) {
+	reportDAO := mgoDB.Collection("player_report")
+	sanctionDAO := mgoDB.Collection("player_sanction")
+	objID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return "", err
+	}
+	snt.ID = primitive.NewObjectID()
+	err = mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
+		err := sctx.StartTransaction(options.Transaction().
+			SetReadConcern(readconcern.Snapshot()).
+			SetWriteConcern(writeconcern.New(writeconcern.WMajority())),
+		)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updateFields := bson.M{
+			"report_status": models.ESCALATEDREPORT,
+			"handled_by":    handledBy,
+			"handled_time":  now,
+			"sanction_id":   snt.ID.Hex(),
+			"updated_time":  now,
+		}
+		if len(note) > 0 {
+			updateFields["note"] = note
+		}
+		rs, err := reportDAO.UpdateOne(sctx, bson.M{
+			"_id":           objID,
+			"report_status": models.OPENREPORT,
+		}, bson.M{"$set": updateFields})
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		if rs.MatchedCount == 0 {
+			sctx.AbortTransaction(sctx)
+			return errors.New("NotFound")
+		}
+		snt.CreatedTime = now
+		snt.UpdatedTime = now
+		if _, err := sanctionDAO.InsertOne(sctx, snt); err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+
+		err = sctx.CommitTransaction(sctx)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return snt.ID.Hex(), nil
+}
+
+//LinkDevice : use a link code and move the device to the account of its player, in a transaction,
+//an account left without devices is deleted. The player the device leaves is kept, but it is set offline
+//when no account plays it any more, so it does not stay in the queue.
+func LinkDevice(code string, deviceID string, now time.Time) (models.Account, error) {
+	var acc models.Account
+	accountDAO := mgoDB.Collection(AccountCollection)
+	linkCodeDAO := mgoDB.Collection(LinkCodeCollection)
+	statusDAO := mgoDB.Collection(StatusCollection)
+	err := mgoClient.UseSession(context.Background(), func(sctx mongo.SessionContext) error {
+		err := sctx.StartTransaction(options.Transaction().
+			SetReadConcern(readconcern.Snapshot()).
+			SetWriteConcern(writeconcern.New(writeconcern.WMajority())),
+		)
+		if err != nil {
+			return err
+		}
+
+		var lnk models.LinkCode
+		err = linkCodeDAO.FindOneAndUpdate(sctx, validLinkCode(code, now), bson.M{"$set": bson.M{
+			"used_by":   deviceID,
+			"used_time": now,
+		}}).Decode(&lnk)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			if err == mongo.ErrNoDocuments {
+				return errors.New("NotFound")
+			}
+			return err
+		}
+		leftPlayerID := deviceID
+		var left models.Account
+		err = accountDAO.FindOne(sctx, bson.M{"device_ids": deviceID}).Decode(&left)
+		if err == nil {
+			leftPlayerID = left.PlayerID
+		} else if err != mongo.ErrNoDocuments {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		_, err = accountDAO.UpdateMany(sctx, bson.M{"device_ids": deviceID}, bson.M{
+			"$pull": bson.M{"device_ids": deviceID},
+			"$set":  bson.M{"updated_time": now},
+		})
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		_, err = accountDAO.DeleteMany(sctx, bson.M{"device_ids": bson.M{"$size": 0}})
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		if leftPlayerID != lnk.PlayerID {
+			count, err := accountDAO.CountDocuments(sctx, bson.M{"player_id": leftPlayerID})
+			if err != nil {
 				sctx.AbortTransaction(sctx)
-				return rs.Err()
+				return err
 			}
+			if count == 0 {
+				_, err = statusDAO.UpdateOne(sctx, bson.M{"device_id": leftPlayerID}, bson.M{"$set": bson.M{
+					"player_status": models.OFFLINE,
+					"updated_time":  now,
+				}})
+				if err != nil {
+					sctx.AbortTransaction(sctx)
+					return err
+				}
+			}
+		}
+		// - The player of a device not migrated yet has no account, it is created with that device
+		_, err = accountDAO.UpdateOne(sctx, bson.M{"player_id": lnk.PlayerID}, bson.M{
+			"$setOnInsert": bson.M{
+				"device_ids":   []string{lnk.CreatedBy},
+				"created_time": now,
+			},
+		}, options.Update().SetUpsert(true))
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
+		}
+		err = accountDAO.FindOneAndUpdate(sctx, bson.M{"player_id": lnk.PlayerID}, bson.M{
+			"$addToSet": bson.M{"device_ids": deviceID},
+			"$set":      bson.M{"updated_time": now},
+		}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&acc)
+		if err != nil {
+			sctx.AbortTransaction(sctx)
+			return err
 		}
 
 		err = sctx.CommitTransaction(sctx)
@@ -125,4 +543,5 @@ func VerifyAndUpdateMMR(matches []models.Match, playerMMRs map[string]int64) err
 		}
 		return nil
 	})
+	return acc, err
 }
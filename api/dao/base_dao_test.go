@@ -0,0 +1,70 @@
+package dao
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChangedFields(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     map[string]interface{}
+		after      map[string]interface{}
+		wantBefore map[string]interface{}
+		wantAfter  map[string]interface{}
+	}{
+		{
+			name:       "nothing changed",
+			before:     map[string]interface{}{"player_mmr": int64(10), "player_name": "Sam"},
+			after:      map[string]interface{}{"player_mmr": int64(10), "player_name": "Sam"},
+			wantBefore: map[string]interface{}{},
+			wantAfter:  map[string]interface{}{},
+		},
+		{
+			name:       "a changed field",
+			before:     map[string]interface{}{"player_mmr": int64(10), "player_name": "Sam"},
+			after:      map[string]interface{}{"player_mmr": int64(12), "player_name": "Sam"},
+			wantBefore: map[string]interface{}{"player_mmr": int64(10)},
+			wantAfter:  map[string]interface{}{"player_mmr": int64(12)},
+		},
+		{
+			name:       "an added field",
+			before:     map[string]interface{}{},
+			after:      map[string]interface{}{"player_nation": "KR"},
+			wantBefore: map[string]interface{}{"player_nation": nil},
+			wantAfter:  map[string]interface{}{"player_nation": "KR"},
+		},
+		{
+			name:       "a removed field",
+			before:     map[string]interface{}{"player_nation": "KR"},
+			after:      map[string]interface{}{},
+			wantBefore: map[string]interface{}{"player_nation": "KR"},
+			wantAfter:  map[string]interface{}{},
+		},
+		{
+			name:       "a different type is a change",
+			before:     map[string]interface{}{"player_mmr": int32(10)},
+			after:      map[string]interface{}{"player_mmr": int64(10)},
+			wantBefore: map[string]interface{}{"player_mmr": int32(10)},
+			wantAfter:  map[string]interface{}{"player_mmr": int64(10)},
+		},
+		{
+			name:       "no before",
+			before:     nil,
+			after:      map[string]interface{}{"player_name": "Sam"},
+			wantBefore: map[string]interface{}{"player_name": nil},
+			wantAfter:  map[string]interface{}{"player_name": "Sam"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotBefore, gotAfter := ChangedFields(tt.before, tt.after)
+			if !reflect.DeepEqual(gotBefore, tt.wantBefore) {
+				t.Errorf("before = %v, want %v", gotBefore, tt.wantBefore)
+			}
+			if !reflect.DeepEqual(gotAfter, tt.wantAfter) {
+				t.Errorf("after = %v, want %v", gotAfter, tt.wantAfter)
+			}
+		})
+	}
+}
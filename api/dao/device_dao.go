@@ -0,0 +1,125 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//DeviceDAO : registered devices
+type DeviceDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *DeviceDAO) Setup() {
+	m.c = mgoDB.Collection("device_info")
+	m.timeOut = 3 * time.Second
+}
+
+//Exist : check if the device is registered or not.
+func (m *DeviceDAO) Exist(id string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	opts := options.Count().SetMaxTime(2 * time.Second)
+	num, err := m.c.CountDocuments(ctx, bson.M{"device_id": id}, opts)
+	return num != 0, err
+}
+
+//FindByID : find a device by its id.
+func (m *DeviceDAO) FindByID(id string) (models.Device, error) {
+	var dev models.Device
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOne(ctx, bson.M{"device_id": id}).Decode(&dev)
+	return dev, err
+}
+
+//Register : insert a new device with the hash of its secret, fail if it is registered.
+func (m *DeviceDAO) Register(dev models.Device) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	now := time.Now()
+	rs, err := m.c.UpdateOne(ctx, bson.M{"device_id": dev.DeviceID}, bson.M{
+		"$setOnInsert": bson.M{
+			"secret_hash":  dev.SecretHash,
+			"updated_time": now,
+			"created_time": now,
+		},
+	}, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if rs.UpsertedCount == 0 {
+		return errors.New("Exist")
+	}
+	return nil
+}
+
+//SetSecret : replace the secret hash of a registered device and revoke its tokens, returns the new token generation.
+func (m *DeviceDAO) SetSecret(deviceID string, secretHash string) (int64, error) {
+	var dev models.Device
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOneAndUpdate(ctx, bson.M{"device_id": deviceID}, bson.M{
+		"$set": bson.M{
+			"secret_hash":  secretHash,
+			"updated_time": time.Now(),
+		},
+		"$inc": bson.M{"token_generation": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&dev)
+	if err == mongo.ErrNoDocuments {
+		return 0, errors.New("NotFound")
+	}
+	return dev.TokenGeneration, err
+}
+
+//SetClaim : let the holder of a claim code set the secret of a device until the code expires,
+//the device is reserved if it is not registered yet.
+func (m *DeviceDAO) SetClaim(deviceID string, claimHash string, expiresTime time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	now := time.Now()
+	_, err := m.c.UpdateOne(ctx, bson.M{"device_id": deviceID}, bson.M{
+		"$set": bson.M{
+			"claim_hash":         claimHash,
+			"claim_expires_time": expiresTime,
+			"updated_time":       now,
+		},
+		"$setOnInsert": bson.M{"created_time": now},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+//Claim : use a claim code to replace the secret of a device and revoke its tokens, the code can only be used once.
+//It returns the new token generation.
+func (m *DeviceDAO) Claim(deviceID string, claimHash string, secretHash string, now time.Time) (int64, error) {
+	var dev models.Device
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOneAndUpdate(ctx, bson.M{
+		"device_id":          deviceID,
+		"claim_hash":         claimHash,
+		"claim_expires_time": bson.M{"$gt": now},
+	}, bson.M{
+		"$set": bson.M{
+			"secret_hash":  secretHash,
+			"updated_time": now,
+		},
+		"$unset": bson.M{
+			"claim_hash":         "",
+			"claim_expires_time": "",
+		},
+		"$inc": bson.M{"token_generation": 1},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&dev)
+	if err == mongo.ErrNoDocuments {
+		return 0, errors.New("NotFound")
+	}
+	return dev.TokenGeneration, err
+}
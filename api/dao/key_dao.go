@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//KeyDAO : app keys
+type KeyDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *KeyDAO) Setup() {
+	m.c = mgoDB.Collection("api_key")
+	m.timeOut = 3 * time.Second
+}
+
+//FindAll : find all keys, revoked ones included.
+func (m *KeyDAO) FindAll() ([]models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	cur, err := m.c.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.APIKey
+	for cur.Next(ctx) {
+		var elem models.APIKey
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
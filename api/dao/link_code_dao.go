@@ -0,0 +1,57 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//LinkCodeCollection : collection of the codes to link devices to accounts
+const LinkCodeCollection = "link_code"
+
+//LinkCodeDAO : the one-time codes to link devices to accounts
+type LinkCodeDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *LinkCodeDAO) Setup() {
+	m.c = mgoDB.Collection(LinkCodeCollection)
+	m.timeOut = 3 * time.Second
+}
+
+//Insert : add a link code.
+func (m *LinkCodeDAO) Insert(code models.LinkCode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	code.ID = primitive.NewObjectID()
+	code.CreatedTime = time.Now()
+	_, err := m.c.InsertOne(ctx, code)
+	return err
+}
+
+//FindValid : find a link code which is neither used nor expired.
+func (m *LinkCodeDAO) FindValid(code string, now time.Time) (models.LinkCode, error) {
+	var lnk models.LinkCode
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOne(ctx, validLinkCode(code, now)).Decode(&lnk)
+	if err == mongo.ErrNoDocuments {
+		return lnk, errors.New("NotFound")
+	}
+	return lnk, err
+}
+
+func validLinkCode(code string, now time.Time) bson.M {
+	return bson.M{
+		"code":         code,
+		"used_time":    bson.M{"$exists": false},
+		"expires_time": bson.M{"$gt": now},
+	}
+}
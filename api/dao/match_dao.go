@@ -4,6 +4,7 @@ import (
 	"context"
 	"earthshaker/api/models"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -22,6 +23,10 @@ type MatchDAO struct {
 func (m *MatchDAO) Setup() {
 	m.c = mgoDB.Collection("match_info")
 	m.timeOut = 3 * time.Second
+	ensureIndex(m.c, mongo.IndexModel{
+		Keys:    bson.D{{Key: "match_status", Value: 1}, {Key: "achieved_time", Value: 1}, {Key: "updated_time", Value: 1}},
+		Options: options.Index().SetName("unachieved"),
+	})
 }
 
 //Exist : check if the player is exist or not.
@@ -37,6 +42,17 @@ func (m *MatchDAO) Exist(id string) (bool, error) {
 	return num != 0, err
 }
 
+//ExistMatchOf : check if a device has played or is playing any match.
+func (m *MatchDAO) ExistMatchOf(deviceID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	opts := options.Count().SetMaxTime(2 * time.Second).SetLimit(1)
+	num, err := m.c.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}},
+	}, opts)
+	return num != 0, err
+}
+
 //FindByID : find a player status by its id.
 func (m *MatchDAO) FindByID(id string) (models.Match, error) {
 	var mch models.Match
@@ -73,6 +89,27 @@ func (m *MatchDAO) FindMatchOf(deviceID string) (models.Match, error) {
 	return mch, err
 }
 
+//FindStartedMatchOf : find the started match of a device.
+func (m *MatchDAO) FindStartedMatchOf(deviceID string) (models.Match, error) {
+	var mch models.Match
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	var conditions = bson.M{
+		"$or":          []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}},
+		"match_status": models.START,
+	}
+	opts := options.Count().SetMaxTime(2 * time.Second)
+	num, err := m.c.CountDocuments(ctx, conditions, opts)
+	if err != nil {
+		return mch, err
+	}
+	if num == 0 {
+		return mch, errors.New("NotFound")
+	}
+	err = m.c.FindOne(ctx, conditions).Decode(&mch)
+	return mch, err
+}
+
 //IsReadyMatch :
 func (m *MatchDAO) IsReadyMatch(deviceID string, matchID string) (models.Match, error) {
 	// fmt.Println("Get ready match " + deviceID)
@@ -88,6 +125,9 @@ func (m *MatchDAO) IsReadyMatch(deviceID string, matchID string) (models.Match,
 	} else if mch.MatchStatus == models.WAIT && deviceID != mch.FirstConnectID {
 		// fmt.Println("Set start match with " + deviceID)
 		mch.MatchStatus = models.START
+		mch.StartedTime = time.Now()
+		mch.Device1SeenTime = mch.StartedTime
+		mch.Device2SeenTime = mch.StartedTime
 		err = m.Upsert(mch)
 	}
 	if err != nil {
@@ -139,14 +179,20 @@ func (m *MatchDAO) Upsert(mch models.Match) error {
 		if len(mch.LoserID) > 0 {
 			updateFields["loser_id"] = mch.LoserID
 		}
-		if len(mch.WebRTCOffer) > 0 {
-			updateFields["webrtc_offer"] = mch.WebRTCOffer
+		if len(mch.EndReason) > 0 {
+			updateFields["end_reason"] = mch.EndReason
+		}
+		if len(mch.GameMode) > 0 {
+			updateFields["game_mode"] = mch.GameMode
+		}
+		if !mch.StartedTime.IsZero() {
+			updateFields["started_time"] = mch.StartedTime
 		}
-		if len(mch.WebRTCCandidates) > 0 {
-			updateFields["webrtc_candidates"] = mch.WebRTCCandidates
+		if !mch.Device1SeenTime.IsZero() {
+			updateFields["device1_seen_time"] = mch.Device1SeenTime
 		}
-		if len(mch.WebRTCAnswer) > 0 {
-			updateFields["webrtc_answer"] = mch.WebRTCAnswer
+		if !mch.Device2SeenTime.IsZero() {
+			updateFields["device2_seen_time"] = mch.Device2SeenTime
 		}
 		_, err = m.c.UpdateOne(ctx, bson.M{"_id": primitive.ObjectID(mch.ID)}, bson.M{"$set": updateFields})
 	} else {
@@ -157,7 +203,8 @@ func (m *MatchDAO) Upsert(mch models.Match) error {
 	return err
 }
 
-//FindAllActiveMatches :
+//FindAllActiveMatches : find the matches created before the duration which have not started or have a result.
+//A started match without result is left to the match clocks and the forfeit of disconnected players.
 func (m *MatchDAO) FindAllActiveMatches(duration time.Duration) ([]models.Match, error) {
 	pivotTime := time.Now().Add(-duration)
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
@@ -167,7 +214,13 @@ func (m *MatchDAO) FindAllActiveMatches(duration time.Duration) ([]models.Match,
 	conditions["$or"] = []bson.M{
 		bson.M{"match_status": models.INIT},
 		bson.M{"match_status": models.WAIT},
-		bson.M{"match_status": models.START},
+		bson.M{
+			"match_status": models.START,
+			"$or": []bson.M{
+				bson.M{"winner_id": bson.M{"$exists": true}},
+				bson.M{"loser_id": bson.M{"$exists": true}},
+			},
+		},
 	}
 	conditions["created_time"] = bson.M{
 		"$lt": pivotTime,
@@ -195,19 +248,96 @@ func (m *MatchDAO) FindAllActiveMatches(duration time.Duration) ([]models.Match,
 	return results, nil
 }
 
-//FindLastestMatchesOf :
-func (m *MatchDAO) FindLastestMatchesOf(deviceID string, limit int64) ([]models.Match, error) {
+//FindLiveMatches : find the lastest started matches, without moves.
+func (m *MatchDAO) FindLiveMatches(limit int64) ([]models.Match, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
 	defer cancel()
 	findOptions := options.Find()
 	findOptions.SetLimit(limit)
 	findOptions.SetSort(bson.M{
-		"created_time": -1,
+		"started_time": -1,
 	})
-	var conditions = bson.M{
-		"$or":          []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}},
-		"match_status": models.END,
+	findOptions.SetProjection(bson.M{
+		"moves":           0,
+		"webrtc_messages": 0,
+	})
+	conditions := bson.M{}
+	conditions["match_status"] = models.START
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Match
+	for cur.Next(ctx) {
+		var elem models.Match
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//DecideResult : set the result fields of a running match, only if the fields it sets are not set yet.
+//A match status is only set if the match has no result at all. The match ends when its first result is set.
+//It returns a "Decided" error if another result was written first.
+func (m *MatchDAO) DecideResult(mch models.Match) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	conditions := bson.M{
+		"_id":          mch.ID,
+		"match_status": bson.M{"$nin": []string{models.END, models.ERR, models.INV}},
+	}
+	updateFields := bson.M{"updated_time": time.Now()}
+	if len(mch.WinnerID) > 0 {
+		conditions["winner_id"] = bson.M{"$exists": false}
+		updateFields["winner_id"] = mch.WinnerID
+	}
+	if len(mch.LoserID) > 0 {
+		conditions["loser_id"] = bson.M{"$exists": false}
+		updateFields["loser_id"] = mch.LoserID
+	}
+	if len(mch.EndReason) > 0 {
+		updateFields["end_reason"] = mch.EndReason
+	}
+	if len(mch.MatchStatus) > 0 {
+		conditions["winner_id"] = bson.M{"$exists": false}
+		conditions["loser_id"] = bson.M{"$exists": false}
+		updateFields["match_status"] = mch.MatchStatus
+	}
+	rs, err := m.c.UpdateOne(ctx, conditions, bson.M{
+		"$set": updateFields,
+		"$min": bson.M{"ended_time": updateFields["updated_time"]},
+	})
+	if err != nil {
+		return err
 	}
+	if rs.MatchedCount == 0 {
+		return errors.New("Decided")
+	}
+	return nil
+}
+
+//FindUndecidedMatches : find started matches without result.
+func (m *MatchDAO) FindUndecidedMatches() ([]models.Match, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetProjection(bson.M{
+		"webrtc_messages": 0,
+	})
+	conditions := bson.M{}
+	conditions["match_status"] = models.START
+	conditions["winner_id"] = bson.M{"$exists": false}
+	conditions["loser_id"] = bson.M{"$exists": false}
 	cur, err := m.c.Find(ctx, conditions, findOptions)
 	if err != nil {
 		return nil, err
@@ -231,6 +361,160 @@ func (m *MatchDAO) FindLastestMatchesOf(deviceID string, limit int64) ([]models.
 	return results, nil
 }
 
+//FindDisconnectedMatches : find started matches without result where a device has not been seen for the duration.
+func (m *MatchDAO) FindDisconnectedMatches(duration time.Duration) ([]models.Match, error) {
+	pivotTime := time.Now().Add(-duration)
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	conditions := bson.M{}
+	conditions["match_status"] = models.START
+	conditions["winner_id"] = bson.M{"$exists": false}
+	conditions["loser_id"] = bson.M{"$exists": false}
+	conditions["$or"] = []bson.M{
+		bson.M{"device1_seen_time": bson.M{"$lt": pivotTime}},
+		bson.M{"device2_seen_time": bson.M{"$lt": pivotTime}},
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Match
+	for cur.Next(ctx) {
+		var elem models.Match
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//Touch : mark a device as seen in a match.
+func (m *MatchDAO) Touch(matchID string, deviceID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	objID, err := primitive.ObjectIDFromHex(matchID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	rs, err := m.c.UpdateOne(ctx, bson.M{"_id": objID, "device1_id": deviceID}, bson.M{"$set": bson.M{"device1_seen_time": now}})
+	if err != nil || rs.MatchedCount > 0 {
+		return err
+	}
+	_, err = m.c.UpdateOne(ctx, bson.M{"_id": objID, "device2_id": deviceID}, bson.M{"$set": bson.M{"device2_seen_time": now}})
+	return err
+}
+
+//FindHistoryOf : find the matches of a player, the lastest first, before a match if it is given,
+//with the status of the opponent joined.
+func (m *MatchDAO) FindHistoryOf(deviceID string, filter models.HistoryFilter, before *models.MatchHistory, limit int64) ([]models.MatchHistory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	conditions := bson.M{
+		"$or": []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}},
+	}
+	var and []bson.M
+	if len(filter.OpponentID) > 0 {
+		and = append(and, bson.M{"$or": []bson.M{bson.M{"device1_id": filter.OpponentID}, bson.M{"device2_id": filter.OpponentID}}})
+	}
+	switch filter.Result {
+	case models.WIN:
+		conditions["winner_id"] = deviceID
+	case models.LOSS:
+		conditions["loser_id"] = deviceID
+	case models.DRAW:
+		conditions["match_status"] = models.END
+		conditions["winner_id"] = bson.M{"$exists": false}
+	}
+	if len(filter.MatchStatus) > 0 {
+		and = append(and, bson.M{"match_status": filter.MatchStatus})
+	}
+	if len(filter.GameMode) > 0 {
+		conditions["game_mode"] = filter.GameMode
+	}
+	if len(filter.SeasonID) > 0 {
+		conditions["season_id"] = filter.SeasonID
+	}
+	createdTime := bson.M{}
+	if !filter.From.IsZero() {
+		createdTime["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		createdTime["$lt"] = filter.To
+	}
+	if len(createdTime) > 0 {
+		conditions["created_time"] = createdTime
+	}
+	if before != nil {
+		and = append(and, bson.M{"$or": []bson.M{
+			bson.M{"created_time": bson.M{"$lt": before.CreatedTime}},
+			bson.M{"created_time": before.CreatedTime, "_id": bson.M{"$lt": before.ID}},
+		}})
+	}
+	if len(and) > 0 {
+		conditions["$and"] = and
+	}
+	cur, err := m.c.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: conditions}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "created_time", Value: -1}, {Key: "_id", Value: -1}}}},
+		bson.D{{Key: "$limit", Value: limit}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"match_status": 1,
+			"game_mode":    1,
+			"season_id":    1,
+			"winner_id":    1,
+			"loser_id":     1,
+			"end_reason":   1,
+			"started_time": 1,
+			"created_time": 1,
+			"updated_time": 1,
+			"opponent_id": bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{"$device1_id", deviceID}}, "$device2_id", "$device1_id",
+			}},
+		}}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "player_status",
+			"localField":   "opponent_id",
+			"foreignField": "device_id",
+			"as":           "opponent",
+		}}},
+		bson.D{{Key: "$unwind", Value: bson.M{
+			"path":                       "$opponent",
+			"preserveNullAndEmptyArrays": true,
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.MatchHistory
+	for cur.Next(ctx) {
+		var elem models.MatchHistory
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
 //AppendMove :
 func (m *MatchDAO) AppendMove(matchID string, mv models.Move) error {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
@@ -255,6 +539,9 @@ func (m *MatchDAO) AppendMove(matchID string, mv models.Move) error {
 	conditions := bson.M{}
 	conditions["_id"] = objID
 	conditions["match_status"] = models.START
+	conditions["winner_id"] = bson.M{"$exists": false}
+	conditions["loser_id"] = bson.M{"$exists": false}
+	conditions["$or"] = []bson.M{bson.M{"device1_id": mv.DeviceID}, bson.M{"device2_id": mv.DeviceID}}
 	updateTerms := bson.M{}
 	updateTerms["$push"] = bson.M{
 		"moves": mv,
@@ -266,11 +553,93 @@ func (m *MatchDAO) AppendMove(matchID string, mv models.Move) error {
 	return nil
 }
 
-//FindMove :
-func (m *MatchDAO) FindMove(matchID string, seq int) (models.Match, error) {
-	var mch models.Match
+//Search : find matches of a device, with a status, in a season, created in [from, to), empty values match all.
+func (m *MatchDAO) Search(deviceID string, status string, seasonID string, from time.Time, to time.Time, skip int64, limit int64) ([]models.Match, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
 	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetSkip(skip)
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"created_time": -1,
+	})
+	findOptions.SetProjection(bson.M{
+		"moves":           0,
+		"webrtc_messages": 0,
+	})
+	conditions := bson.M{}
+	if len(deviceID) > 0 {
+		conditions["$or"] = []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}}
+	}
+	if len(status) > 0 {
+		conditions["match_status"] = status
+	}
+	if len(seasonID) > 0 {
+		conditions["season_id"] = seasonID
+	}
+	createdTime := bson.M{}
+	if !from.IsZero() {
+		createdTime["$gte"] = from
+	}
+	if !to.IsZero() {
+		createdTime["$lt"] = to
+	}
+	if len(createdTime) > 0 {
+		conditions["created_time"] = createdTime
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Match
+	for cur.Next(ctx) {
+		var elem models.Match
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//AppendWebRTCMessage : append a signaling message to a match that is not over.
+func (m *MatchDAO) AppendWebRTCMessage(matchID string, msg models.WebRTCMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	objID, err := primitive.ObjectIDFromHex(matchID)
+	if err != nil {
+		return err
+	}
+	conditions := bson.M{}
+	conditions["_id"] = objID
+	conditions["match_status"] = bson.M{"$in": []string{models.INIT, models.WAIT, models.START}}
+	conditions["$or"] = []bson.M{bson.M{"device1_id": msg.FromID}, bson.M{"device2_id": msg.FromID}}
+	conditions[fmt.Sprintf("webrtc_messages.%d", models.MaxWebRTCMessages-1)] = bson.M{"$exists": false}
+	updateTerms := bson.M{}
+	updateTerms["$push"] = bson.M{
+		"webrtc_messages": msg,
+	}
+	rs, err := m.c.UpdateOne(ctx, conditions, updateTerms)
+	if err != nil {
+		return err
+	}
+	if rs.MatchedCount == 0 {
+		return errors.New("NotFound")
+	}
+	return nil
+}
+
+//FindMove : find a move of a match for one of its players.
+func (m *MatchDAO) FindMove(matchID string, deviceID string, seq int) (models.Match, error) {
+	var mch models.Match
 	objID, err := primitive.ObjectIDFromHex(matchID)
 	if err != nil {
 		return mch, err
@@ -291,7 +660,31 @@ func (m *MatchDAO) FindMove(matchID string, seq int) (models.Match, error) {
 	conditions := bson.M{}
 	conditions["_id"] = objID
 	conditions["match_status"] = models.START
-	conditions["moves.sequence"] = seq
+	conditions["$or"] = []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}}
+	return m.findMove(conditions, bson.M{"sequence": seq})
+}
+
+//FindDelayedMove : find a move of a match that was made before the given time, for spectators.
+func (m *MatchDAO) FindDelayedMove(matchID string, seq int, before time.Time) (models.Match, error) {
+	var mch models.Match
+	objID, err := primitive.ObjectIDFromHex(matchID)
+	if err != nil {
+		return mch, err
+	}
+	conditions := bson.M{}
+	conditions["_id"] = objID
+	conditions["match_status"] = models.START
+	return m.findMove(conditions, bson.M{
+		"sequence":     seq,
+		"created_time": bson.M{"$lte": before},
+	})
+}
+
+func (m *MatchDAO) findMove(conditions bson.M, moveConditions bson.M) (models.Match, error) {
+	var mch models.Match
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	conditions["moves"] = bson.M{"$elemMatch": moveConditions}
 	num, err := m.c.CountDocuments(ctx, conditions)
 	if err != nil {
 		return mch, err
@@ -302,11 +695,342 @@ func (m *MatchDAO) FindMove(matchID string, seq int) (models.Match, error) {
 	opts := options.FindOne()
 	opts.SetProjection(bson.M{
 		"moves": bson.M{
-			"$elemMatch": bson.M{
-				"sequence": seq,
-			},
+			"$elemMatch": moveConditions,
 		},
 	})
 	err = m.c.FindOne(ctx, conditions, opts).Decode(&mch)
 	return mch, err
 }
+
+//AggregateNationResults : the wins and games of every nation in the matches ended since a time.
+func (m *MatchDAO) AggregateNationResults(since time.Time) ([]models.NationStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	cur, err := m.c.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"match_status": models.END,
+			"winner_id":    bson.M{"$exists": true},
+			"loser_id":     bson.M{"$exists": true},
+			"updated_time": bson.M{"$gte": since},
+		}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"players": []bson.M{
+				bson.M{"device_id": "$winner_id", "win": 1},
+				bson.M{"device_id": "$loser_id", "win": 0},
+			},
+		}}},
+		bson.D{{Key: "$unwind", Value: "$players"}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "player_status",
+			"localField":   "players.device_id",
+			"foreignField": "device_id",
+			"as":           "status",
+		}}},
+		bson.D{{Key: "$unwind", Value: "$status"}},
+		bson.D{{Key: "$match", Value: bson.M{
+			"status.player_nation": bson.M{"$exists": true, "$ne": ""},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":   "$status.player_nation",
+			"wins":  bson.M{"$sum": "$players.win"},
+			"games": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.NationStats
+	for cur.Next(ctx) {
+		var elem models.NationStats
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//AggregateStatsOf : the record of a player in the ended matches of a season, all seasons if it is empty,
+//with the win streaks, the most played opponents and the record against an opponent if it is given.
+//Only the matches created until a time are counted, all of them if it is zero.
+func (m *MatchDAO) AggregateStatsOf(deviceID string, opponentID string, seasonID string, until time.Time, opponentLimit int64) (models.PlayerStats, error) {
+	var stats models.PlayerStats
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	conditions := bson.M{
+		"$or":          []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}},
+		"match_status": models.END,
+	}
+	if len(seasonID) > 0 {
+		conditions["season_id"] = seasonID
+	}
+	if !until.IsZero() {
+		conditions["created_time"] = bson.M{"$lte": until}
+	}
+	resultCount := func(result string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{"$result", result}}, 1, 0}}}
+	}
+	opponentGroup := bson.D{{Key: "$group", Value: bson.M{
+		"_id":    "$opponent_id",
+		"games":  bson.M{"$sum": 1},
+		"wins":   resultCount(models.WIN),
+		"losses": resultCount(models.LOSS),
+		"draws":  resultCount(models.DRAW),
+	}}}
+	facets := bson.M{
+		"totals": []bson.D{
+			bson.D{{Key: "$group", Value: bson.M{
+				"_id":                   nil,
+				"games":                 bson.M{"$sum": 1},
+				"wins":                  resultCount(models.WIN),
+				"losses":                resultCount(models.LOSS),
+				"draws":                 resultCount(models.DRAW),
+				"average_match_seconds": bson.M{"$avg": "$match_seconds"},
+			}}},
+		},
+		"opponents": []bson.D{
+			opponentGroup,
+			bson.D{{Key: "$sort", Value: bson.D{{Key: "games", Value: -1}, {Key: "_id", Value: 1}}}},
+			bson.D{{Key: "$limit", Value: opponentLimit}},
+		},
+		"streaks": []bson.D{
+			bson.D{{Key: "$sort", Value: bson.M{"created_time": 1}}},
+			bson.D{{Key: "$group", Value: bson.M{"_id": nil, "results": bson.M{"$push": "$result"}}}},
+			bson.D{{Key: "$project", Value: bson.M{"_id": 0, "streaks": bson.M{"$reduce": bson.M{
+				"input":        "$results",
+				"initialValue": bson.M{"current": 0, "best": 0},
+				"in": bson.M{"$cond": []interface{}{
+					bson.M{"$eq": []interface{}{"$$this", models.WIN}},
+					bson.M{
+						"current": bson.M{"$add": []interface{}{"$$value.current", 1}},
+						"best":    bson.M{"$max": []interface{}{"$$value.best", bson.M{"$add": []interface{}{"$$value.current", 1}}}},
+					},
+					bson.M{"current": 0, "best": "$$value.best"},
+				}},
+			}}}}},
+		},
+	}
+	if len(opponentID) > 0 {
+		facets["head_to_head"] = []bson.D{
+			bson.D{{Key: "$match", Value: bson.M{"opponent_id": opponentID}}},
+			opponentGroup,
+		}
+	}
+	cur, err := m.c.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: conditions}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"created_time": 1,
+			"opponent_id": bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{"$device1_id", deviceID}}, "$device2_id", "$device1_id",
+			}},
+			"result": bson.M{"$switch": bson.M{
+				"branches": []bson.M{
+					bson.M{"case": bson.M{"$eq": []interface{}{"$winner_id", deviceID}}, "then": models.WIN},
+					bson.M{"case": bson.M{"$eq": []interface{}{"$loser_id", deviceID}}, "then": models.LOSS},
+				},
+				"default": models.DRAW,
+			}},
+			"match_seconds": bson.M{"$let": bson.M{
+				"vars": bson.M{"ended": bson.M{"$ifNull": []interface{}{"$ended_time", bson.M{"$max": "$moves.created_time"}}}},
+				"in": bson.M{"$cond": []interface{}{
+					bson.M{"$and": []interface{}{
+						bson.M{"$ifNull": []interface{}{"$started_time", false}},
+						bson.M{"$ifNull": []interface{}{"$$ended", false}},
+					}},
+					bson.M{"$divide": []interface{}{bson.M{"$subtract": []interface{}{"$$ended", "$started_time"}}, 1000}},
+					nil,
+				}},
+			}},
+		}}},
+		bson.D{{Key: "$facet", Value: facets}},
+	})
+	if err != nil {
+		return stats, err
+	}
+	defer cur.Close(ctx)
+
+	var facet struct {
+		Totals     []models.PlayerStats   `bson:"totals"`
+		Opponents  []models.OpponentStats `bson:"opponents"`
+		HeadToHead []models.OpponentStats `bson:"head_to_head"`
+		Streaks    []struct {
+			Streaks struct {
+				Current int64 `bson:"current"`
+				Best    int64 `bson:"best"`
+			} `bson:"streaks"`
+		} `bson:"streaks"`
+	}
+	if cur.Next(ctx) {
+		if err := cur.Decode(&facet); err != nil {
+			return stats, err
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return stats, err
+	}
+	if len(facet.Totals) > 0 {
+		stats = facet.Totals[0]
+	}
+	stats.Opponents = facet.Opponents
+	if len(facet.HeadToHead) > 0 {
+		stats.HeadToHead = &facet.HeadToHead[0]
+	}
+	if len(facet.Streaks) > 0 {
+		stats.CurrentStreak = facet.Streaks[0].Streaks.Current
+		stats.BestStreak = facet.Streaks[0].Streaks.Best
+	}
+	return stats, nil
+}
+
+//FindUnachievedMatches : find ended matches whose achievements are not evaluated yet, the oldest first.
+func (m *MatchDAO) FindUnachievedMatches(limit int64) ([]models.Match, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"updated_time": 1,
+	})
+	findOptions.SetProjection(bson.M{
+		"moves":           0,
+		"webrtc_messages": 0,
+	})
+	conditions := bson.M{
+		"match_status":  models.END,
+		"achieved_time": bson.M{"$exists": false},
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Match
+	for cur.Next(ctx) {
+		var elem models.Match
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//MarkAchieved : the achievements of the match are evaluated.
+func (m *MatchDAO) MarkAchieved(matchID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	_, err := m.c.UpdateOne(ctx, bson.M{"_id": matchID}, bson.M{
+		"$set": bson.M{"achieved_time": time.Now()},
+	})
+	return err
+}
+
+//CountOpponentNationsOf : the number of nations of the opponents of a player in ended matches,
+//only the matches created until a time are counted, all of them if it is zero.
+func (m *MatchDAO) CountOpponentNationsOf(deviceID string, until time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	conditions := bson.M{
+		"$or":          []bson.M{bson.M{"device1_id": deviceID}, bson.M{"device2_id": deviceID}},
+		"match_status": models.END,
+	}
+	if !until.IsZero() {
+		conditions["created_time"] = bson.M{"$lte": until}
+	}
+	cur, err := m.c.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: conditions}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"opponent_id": bson.M{"$cond": []interface{}{
+				bson.M{"$eq": []interface{}{"$device1_id", deviceID}}, "$device2_id", "$device1_id",
+			}},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$opponent_id"}}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "player_status",
+			"localField":   "_id",
+			"foreignField": "device_id",
+			"as":           "status",
+		}}},
+		bson.D{{Key: "$unwind", Value: "$status"}},
+		bson.D{{Key: "$match", Value: bson.M{
+			"status.player_nation": bson.M{"$exists": true, "$ne": ""},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$status.player_nation"}}},
+		bson.D{{Key: "$count", Value: "nations"}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+	var result struct {
+		Nations int64 `bson:"nations"`
+	}
+	if cur.Next(ctx) {
+		if err := cur.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Nations, cur.Err()
+}
+
+//FindFeedOf : find the ended matches of any of the players, the lastest first, before a match if it is given.
+func (m *MatchDAO) FindFeedOf(deviceIDs []string, before *models.Match, limit int64) ([]models.Match, error) {
+	if len(deviceIDs) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.D{{Key: "created_time", Value: -1}, {Key: "_id", Value: -1}})
+	findOptions.SetProjection(bson.M{
+		"moves":           0,
+		"webrtc_messages": 0,
+	})
+	conditions := bson.M{
+		"$or":          []bson.M{bson.M{"device1_id": bson.M{"$in": deviceIDs}}, bson.M{"device2_id": bson.M{"$in": deviceIDs}}},
+		"match_status": models.END,
+	}
+	if before != nil {
+		conditions["$and"] = []bson.M{bson.M{"$or": []bson.M{
+			bson.M{"created_time": bson.M{"$lt": before.CreatedTime}},
+			bson.M{"created_time": before.CreatedTime, "_id": bson.M{"$lt": before.ID}},
+		}}}
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Match
+	for cur.Next(ctx) {
+		var elem models.Match
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//ProfileDAO : the profiles of the players
+type ProfileDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *ProfileDAO) Setup() {
+	m.c = mgoDB.Collection("player_profile")
+	m.timeOut = 3 * time.Second
+}
+
+//FindByID : find the profile of a player.
+func (m *ProfileDAO) FindByID(id string) (models.Profile, error) {
+	var prf models.Profile
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOne(ctx, bson.M{"device_id": id}).Decode(&prf)
+	if err == mongo.ErrNoDocuments {
+		return prf, errors.New("NotFound")
+	}
+	return prf, err
+}
+
+//FindByIDs : find the profiles of the players, players without a profile are left out.
+func (m *ProfileDAO) FindByIDs(ids []string) ([]models.Profile, error) {
+	var results []models.Profile
+	if len(ids) == 0 {
+		return results, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	cur, err := m.c.Find(ctx, bson.M{"device_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	for cur.Next(ctx) {
+		var elem models.Profile
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//Upsert : create or replace the fields of the profile of a player, empty fields are cleared.
+func (m *ProfileDAO) Upsert(prf models.Profile) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	now := time.Now()
+	_, err := m.c.UpdateOne(ctx, bson.M{"device_id": prf.DeviceID}, bson.M{
+		"$set": bson.M{
+			"avatar_id":     prf.AvatarID,
+			"title":         prf.Title,
+			"player_nation": prf.PlayerNation,
+			"bio":           prf.Bio,
+			"updated_time":  now,
+		},
+		"$setOnInsert": bson.M{"created_time": now},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+//Delete : delete the profile of a player.
+func (m *ProfileDAO) Delete(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	rs, err := m.c.DeleteOne(ctx, bson.M{"device_id": id})
+	if err != nil {
+		return err
+	}
+	if rs.DeletedCount == 0 {
+		return errors.New("NotFound")
+	}
+	return nil
+}
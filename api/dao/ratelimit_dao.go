@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/helper"
+	"earthshaker/api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//RateLimitRetries : max attempts of a token taken concurrently by other instances
+const (
+	RateLimitRetries      = 3
+	RateLimitConflictWait = time.Second
+)
+
+//RateLimitDAO : token buckets shared between instances, idle buckets are dropped by a TTL index on expired_time.
+type RateLimitDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *RateLimitDAO) Setup() {
+	m.c = mgoDB.Collection("rate_limit")
+	m.timeOut = 3 * time.Second
+	ensureIndex(m.c, mongo.IndexModel{
+		Keys:    bson.M{"expired_time": 1},
+		Options: options.Index().SetName("expired_time_ttl").SetExpireAfterSeconds(0),
+	})
+}
+
+//Take : take a token from the bucket of the key, the bucket is only written if nobody changed it meanwhile.
+//A bucket still taken by others after the retries is treated as empty, so a busy key is not let through.
+func (m *RateLimitDAO) Take(key string, rate float64, burst int64, now time.Time) (bool, time.Duration, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	for i := 0; i < RateLimitRetries; i++ {
+		var bucket models.RateBucket
+		err := m.c.FindOne(ctx, bson.M{"_id": key}).Decode(&bucket)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return false, 0, err
+		}
+		exist := err == nil
+		lastUpdated := bucket.UpdatedTime
+		bucket.Key = key
+		ok, retryAfter := helper.TakeToken(&bucket, rate, burst, now)
+		bucket.ExpiredTime = now.Add(helper.BucketIdleTime(rate, burst))
+
+		if !exist {
+			_, err := m.c.InsertOne(ctx, bucket)
+			if isDuplicateKey(err) {
+				continue
+			}
+			if err != nil {
+				return false, 0, err
+			}
+			return ok, retryAfter, nil
+		}
+		rs, err := m.c.UpdateOne(ctx, bson.M{
+			"_id":          key,
+			"updated_time": lastUpdated,
+		}, bson.M{
+			"$set": bson.M{
+				"tokens":       bucket.Tokens,
+				"updated_time": bucket.UpdatedTime,
+				"expired_time": bucket.ExpiredTime,
+			},
+		})
+		if err != nil {
+			return false, 0, err
+		}
+		if rs.MatchedCount == 0 {
+			continue
+		}
+		return ok, retryAfter, nil
+	}
+	return false, RateLimitConflictWait, nil
+}
+
+func isDuplicateKey(err error) bool {
+	if we, ok := err.(mongo.WriteException); ok {
+		for _, e := range we.WriteErrors {
+			if e.Code == 11000 {
+				return true
+			}
+		}
+	}
+	return false
+}
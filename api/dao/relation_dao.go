@@ -0,0 +1,166 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//RelationCollection : collection of the relations between players
+const RelationCollection = "player_relation"
+
+//RelationDAO : friend requests, friendships and blocks between players
+type RelationDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *RelationDAO) Setup() {
+	m.c = mgoDB.Collection(RelationCollection)
+	m.timeOut = 3 * time.Second
+}
+
+//FindBetween : find the relations between two players, in both directions.
+func (m *RelationDAO) FindBetween(deviceID string, targetID string) ([]models.Relation, error) {
+	return m.find(bson.M{"$or": []bson.M{
+		bson.M{"device_id": deviceID, "target_id": targetID},
+		bson.M{"device_id": targetID, "target_id": deviceID},
+	}})
+}
+
+//FindOf : find the relations of a player in a status.
+func (m *RelationDAO) FindOf(deviceID string, status string) ([]models.Relation, error) {
+	return m.find(bson.M{"device_id": deviceID, "relation_status": status})
+}
+
+//FindRequestsTo : find the pending friend requests sent to a player.
+func (m *RelationDAO) FindRequestsTo(deviceID string) ([]models.Relation, error) {
+	return m.find(bson.M{"target_id": deviceID, "relation_status": models.PENDINGFRIEND})
+}
+
+//FindBlocksAmong : find the blocks between any two of the players.
+func (m *RelationDAO) FindBlocksAmong(deviceIDs []string) ([]models.Relation, error) {
+	if len(deviceIDs) < 2 {
+		return nil, nil
+	}
+	return m.find(bson.M{
+		"device_id":       bson.M{"$in": deviceIDs},
+		"target_id":       bson.M{"$in": deviceIDs},
+		"relation_status": models.BLOCKED,
+	})
+}
+
+func (m *RelationDAO) find(conditions bson.M) ([]models.Relation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetSort(bson.M{
+		"created_time": -1,
+	})
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Relation
+	for cur.Next(ctx) {
+		var elem models.Relation
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//Request : send a friend request, sending it again keeps the first one.
+func (m *RelationDAO) Request(deviceID string, targetID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	now := time.Now()
+	_, err := m.c.UpdateOne(ctx, bson.M{
+		"device_id": deviceID,
+		"target_id": targetID,
+	}, bson.M{
+		"$setOnInsert": bson.M{
+			"relation_status": models.PENDINGFRIEND,
+			"updated_time":    now,
+			"created_time":    now,
+		},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+//Decline : delete a pending friend request sent by the requester to the player.
+func (m *RelationDAO) Decline(deviceID string, requesterID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	rs, err := m.c.DeleteOne(ctx, bson.M{
+		"device_id":       requesterID,
+		"target_id":       deviceID,
+		"relation_status": models.PENDINGFRIEND,
+	})
+	if err != nil {
+		return err
+	}
+	if rs.DeletedCount == 0 {
+		return errors.New("NotFound")
+	}
+	return nil
+}
+
+//Remove : end a friendship, or cancel a friend request sent by the player.
+func (m *RelationDAO) Remove(deviceID string, targetID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	rs, err := m.c.DeleteMany(ctx, bson.M{"$or": []bson.M{
+		bson.M{
+			"device_id":       deviceID,
+			"target_id":       targetID,
+			"relation_status": bson.M{"$in": []string{models.PENDINGFRIEND, models.FRIEND}},
+		},
+		bson.M{
+			"device_id":       targetID,
+			"target_id":       deviceID,
+			"relation_status": models.FRIEND,
+		},
+	}})
+	if err != nil {
+		return err
+	}
+	if rs.DeletedCount == 0 {
+		return errors.New("NotFound")
+	}
+	return nil
+}
+
+//Unblock : delete a block of the player.
+func (m *RelationDAO) Unblock(deviceID string, targetID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	rs, err := m.c.DeleteOne(ctx, bson.M{
+		"device_id":       deviceID,
+		"target_id":       targetID,
+		"relation_status": models.BLOCKED,
+	})
+	if err != nil {
+		return err
+	}
+	if rs.DeletedCount == 0 {
+		return errors.New("NotFound")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package dao
+
+import (
+	"earthshaker/api/models"
+	"errors"
+	"sync"
+)
+
+//ReplayDAO : in-memory store of imported replays, used for debugging.
+type ReplayDAO struct {
+	mu      sync.RWMutex
+	replays map[string]models.Match
+}
+
+//Setup : init the store
+func (m *ReplayDAO) Setup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replays = map[string]models.Match{}
+}
+
+//Save : add or replace a replay.
+func (m *ReplayDAO) Save(mch models.Match) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replays[mch.ID.Hex()] = mch
+}
+
+//FindByID : find a replay by its match id.
+func (m *ReplayDAO) FindByID(id string) (models.Match, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	mch, exist := m.replays[id]
+	if !exist {
+		return mch, errors.New("NotFound")
+	}
+	return mch, nil
+}
@@ -0,0 +1,153 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//ReportDAO : player reports, the open ones are the moderation queue.
+type ReportDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *ReportDAO) Setup() {
+	m.c = mgoDB.Collection("player_report")
+	m.timeOut = 3 * time.Second
+}
+
+//Insert : add an open report, returns its id.
+func (m *ReportDAO) Insert(rpt models.Report) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	rpt.ID = primitive.NewObjectID()
+	rpt.ReportStatus = models.OPENREPORT
+	rpt.UpdatedTime = time.Now()
+	rpt.CreatedTime = rpt.UpdatedTime
+	_, err := m.c.InsertOne(ctx, rpt)
+	return rpt.ID.Hex(), err
+}
+
+//ExistOpen : check if the reporter has an open report of the player in the match.
+func (m *ReportDAO) ExistOpen(reporterID string, reportedID string, matchID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	conditions := bson.M{
+		"reporter_id":   reporterID,
+		"reported_id":   reportedID,
+		"match_id":      matchID,
+		"report_status": models.OPENREPORT,
+	}
+	if len(matchID) == 0 {
+		conditions["match_id"] = bson.M{"$exists": false}
+	}
+	count, err := m.c.CountDocuments(ctx, conditions)
+	return count > 0, err
+}
+
+//FindByID : find a report by its id.
+func (m *ReportDAO) FindByID(id string) (models.Report, error) {
+	var rpt models.Report
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return rpt, err
+	}
+	err = m.c.FindOne(ctx, bson.M{"_id": objID}).Decode(&rpt)
+	return rpt, err
+}
+
+//Handle : close an open report as dismissed or escalated.
+func (m *ReportDAO) Handle(id string, status string, handledBy string, note string, sanctionID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	updateFields := bson.M{
+		"report_status": status,
+		"handled_by":    handledBy,
+		"handled_time":  time.Now(),
+		"updated_time":  time.Now(),
+	}
+	if len(note) > 0 {
+		updateFields["note"] = note
+	}
+	if len(sanctionID) > 0 {
+		updateFields["sanction_id"] = sanctionID
+	}
+	rs, err := m.c.UpdateOne(ctx, bson.M{
+		"_id":           objID,
+		"report_status": models.OPENREPORT,
+	}, bson.M{"$set": updateFields})
+	if err != nil {
+		return err
+	}
+	if rs.MatchedCount == 0 {
+		return errors.New("NotFound")
+	}
+	return nil
+}
+
+//Search : find reports by reported device, category and status, the oldest first, empty values match all.
+func (m *ReportDAO) Search(reportedID string, category string, status string, skip int64, limit int64) ([]models.Report, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetSkip(skip)
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"created_time": 1,
+	})
+	conditions := bson.M{}
+	if len(reportedID) > 0 {
+		conditions["reported_id"] = reportedID
+	}
+	if len(category) > 0 {
+		conditions["category"] = category
+	}
+	if len(status) > 0 {
+		conditions["report_status"] = status
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Report
+	for cur.Next(ctx) {
+		var elem models.Report
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//CountOpenOf : the number of open reports of a device.
+func (m *ReportDAO) CountOpenOf(reportedID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	return m.c.CountDocuments(ctx, bson.M{
+		"reported_id":   reportedID,
+		"report_status": models.OPENREPORT,
+	})
+}
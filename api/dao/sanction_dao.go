@@ -0,0 +1,122 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//SanctionDAO : sanctions of devices
+type SanctionDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *SanctionDAO) Setup() {
+	m.c = mgoDB.Collection("player_sanction")
+	m.timeOut = 3 * time.Second
+}
+
+//Insert : add a sanction, returns its id.
+func (m *SanctionDAO) Insert(snt models.Sanction) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	snt.ID = primitive.NewObjectID()
+	snt.UpdatedTime = time.Now()
+	snt.CreatedTime = snt.UpdatedTime
+	_, err := m.c.InsertOne(ctx, snt)
+	return snt.ID.Hex(), err
+}
+
+//Lift : lift an active sanction, it is returned as it was before.
+func (m *SanctionDAO) Lift(id string, liftedBy string) (models.Sanction, error) {
+	var before models.Sanction
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return before, err
+	}
+	conditions := activeConditions(time.Now())
+	conditions["_id"] = objID
+	updateFields := bson.M{
+		"lifted_by":    liftedBy,
+		"lifted_time":  time.Now(),
+		"updated_time": time.Now(),
+	}
+	err = m.c.FindOneAndUpdate(ctx, conditions, bson.M{"$set": updateFields}).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return before, errors.New("NotFound")
+	}
+	return before, err
+}
+
+//FindActiveOf : find the active sanctions of a device.
+func (m *SanctionDAO) FindActiveOf(deviceID string) ([]models.Sanction, error) {
+	conditions := activeConditions(time.Now())
+	conditions["device_id"] = deviceID
+	return m.find(conditions, 0)
+}
+
+//FindActiveOfAny : find the active sanctions of the devices.
+func (m *SanctionDAO) FindActiveOfAny(deviceIDs []string) ([]models.Sanction, error) {
+	if len(deviceIDs) == 0 {
+		return nil, nil
+	}
+	conditions := activeConditions(time.Now())
+	conditions["device_id"] = bson.M{"$in": deviceIDs}
+	return m.find(conditions, 0)
+}
+
+//FindOf : find the lastest sanctions of a device, lifted and expired ones included.
+func (m *SanctionDAO) FindOf(deviceID string, limit int64) ([]models.Sanction, error) {
+	return m.find(bson.M{"device_id": deviceID}, limit)
+}
+
+func (m *SanctionDAO) find(conditions bson.M, limit int64) ([]models.Sanction, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"created_time": -1,
+	})
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Sanction
+	for cur.Next(ctx) {
+		var elem models.Sanction
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+func activeConditions(now time.Time) bson.M {
+	return bson.M{
+		"lifted_time": bson.M{"$exists": false},
+		"$or": []bson.M{
+			bson.M{"expired_time": bson.M{"$exists": false}},
+			bson.M{"expired_time": bson.M{"$gt": now}},
+		},
+	}
+}
@@ -0,0 +1,144 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//SeasonDAO : competitive seasons
+type SeasonDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *SeasonDAO) Setup() {
+	m.c = mgoDB.Collection("season")
+	m.timeOut = 3 * time.Second
+}
+
+//Insert : add a season.
+func (m *SeasonDAO) Insert(ssn models.Season) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	ssn.UpdatedTime = time.Now()
+	ssn.CreatedTime = ssn.UpdatedTime
+	_, err := m.c.InsertOne(ctx, ssn)
+	return err
+}
+
+//ExistOverlap : check if a season has the id or overlaps [start, end).
+func (m *SeasonDAO) ExistOverlap(seasonID string, start time.Time, end time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	count, err := m.c.CountDocuments(ctx, bson.M{
+		"$or": []bson.M{
+			bson.M{"season_id": seasonID},
+			bson.M{"start_time": bson.M{"$lt": end}, "end_time": bson.M{"$gt": start}},
+		},
+	})
+	return count > 0, err
+}
+
+//FindByID : find a season by its season id.
+func (m *SeasonDAO) FindByID(seasonID string) (models.Season, error) {
+	var ssn models.Season
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOne(ctx, bson.M{"season_id": seasonID}).Decode(&ssn)
+	return ssn, err
+}
+
+//FindCurrent : find the season running at the time, NotFound between seasons.
+func (m *SeasonDAO) FindCurrent(now time.Time) (models.Season, error) {
+	seasons, err := m.find(bson.M{
+		"start_time": bson.M{"$lte": now},
+		"end_time":   bson.M{"$gt": now},
+	}, 1, 1)
+	if err != nil {
+		return models.Season{}, err
+	}
+	if len(seasons) == 0 {
+		return models.Season{}, errors.New("NotFound")
+	}
+	return seasons[0], nil
+}
+
+//FindEnded : find the earliest season ended at the time but not rolled over, NotFound if there is none.
+func (m *SeasonDAO) FindEnded(now time.Time) (models.Season, error) {
+	seasons, err := m.find(bson.M{
+		"end_time":         bson.M{"$lte": now},
+		"rolled_over_time": bson.M{"$exists": false},
+	}, 1, 1)
+	if err != nil {
+		return models.Season{}, err
+	}
+	if len(seasons) == 0 {
+		return models.Season{}, errors.New("NotFound")
+	}
+	return seasons[0], nil
+}
+
+//FindAll : find all seasons, the lastest first.
+func (m *SeasonDAO) FindAll() ([]models.Season, error) {
+	return m.find(bson.M{}, -1, 0)
+}
+
+//MarkArchived : the records of the season are archived.
+func (m *SeasonDAO) MarkArchived(seasonID string) error {
+	return m.mark(seasonID, "archived_time")
+}
+
+//MarkRolledOver : the mmr of the players is reset after the season.
+func (m *SeasonDAO) MarkRolledOver(seasonID string) error {
+	return m.mark(seasonID, "rolled_over_time")
+}
+
+func (m *SeasonDAO) mark(seasonID string, field string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	_, err := m.c.UpdateOne(ctx, bson.M{"season_id": seasonID}, bson.M{
+		"$set": bson.M{
+			field:          time.Now(),
+			"updated_time": time.Now(),
+		},
+	})
+	return err
+}
+
+func (m *SeasonDAO) find(conditions bson.M, order int, limit int64) ([]models.Season, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"start_time": order,
+	})
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Season
+	for cur.Next(ctx) {
+		var elem models.Season
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//SeasonRecordDAO : the archived ranks and mmr of the players in past seasons
+type SeasonRecordDAO struct {
+	c       *mongo.Collection
+	timeOut time.Duration
+}
+
+//SeasonRecordCollection : name
+const SeasonRecordCollection = "season_record"
+
+//Setup : Set collection name
+func (m *SeasonRecordDAO) Setup() {
+	m.c = mgoDB.Collection(SeasonRecordCollection)
+	m.timeOut = 3 * time.Second
+}
+
+//Freeze : archive the name, nation and mmr of a player in a season, a record already archived is kept as it is.
+func (m *SeasonRecordDAO) Freeze(rcd models.SeasonRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	_, err := m.c.UpdateOne(ctx, bson.M{
+		"season_id": rcd.SeasonID,
+		"device_id": rcd.DeviceID,
+	}, bson.M{
+		"$setOnInsert": bson.M{
+			"player_name":   rcd.PlayerName,
+			"player_nation": rcd.PlayerNation,
+			"final_rank":    0,
+			"final_mmr":     rcd.FinalMMR,
+			"reset_mmr":     rcd.ResetMMR,
+			"created_time":  time.Now(),
+		},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+//SetRanks : set the final rank and nation rank of a record.
+func (m *SeasonRecordDAO) SetRanks(rcd models.SeasonRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	_, err := m.c.UpdateOne(ctx, bson.M{"_id": rcd.ID}, bson.M{
+		"$set": bson.M{
+			"final_rank":        rcd.FinalRank,
+			"final_nation_rank": rcd.FinalNationRank,
+		},
+	})
+	return err
+}
+
+//FindFrozenAfter : find the records of a season after a record in the order of their frozen mmr, from the top if it is nil.
+func (m *SeasonRecordDAO) FindFrozenAfter(seasonID string, after *models.SeasonRecord, limit int64) ([]models.SeasonRecord, error) {
+	conditions := bson.M{"season_id": seasonID}
+	if after != nil {
+		conditions["$or"] = []bson.M{
+			bson.M{"final_mmr": bson.M{"$lt": after.FinalMMR}},
+			bson.M{"final_mmr": after.FinalMMR, "device_id": bson.M{"$gt": after.DeviceID}},
+		}
+	}
+	return m.find(conditions, bson.D{
+		{Key: "final_mmr", Value: -1},
+		{Key: "device_id", Value: 1},
+	}, limit)
+}
+
+//FindNotReset : find records of a season whose reset is not applied yet.
+func (m *SeasonRecordDAO) FindNotReset(seasonID string, limit int64) ([]models.SeasonRecord, error) {
+	return m.find(bson.M{
+		"season_id":  seasonID,
+		"reset_time": bson.M{"$exists": false},
+	}, bson.D{{Key: "final_rank", Value: 1}}, limit)
+}
+
+//FindRankedAfter : find the records of a season, of a nation if it is given, ranked after a final rank.
+func (m *SeasonRecordDAO) FindRankedAfter(seasonID string, nation string, rank int64, limit int64) ([]models.SeasonRecord, error) {
+	if len(nation) > 0 {
+		return m.find(bson.M{
+			"season_id":         seasonID,
+			"player_nation":     nation,
+			"final_nation_rank": bson.M{"$gt": rank},
+		}, bson.D{{Key: "final_nation_rank", Value: 1}}, limit)
+	}
+	return m.find(bson.M{
+		"season_id":  seasonID,
+		"final_rank": bson.M{"$gt": rank},
+	}, bson.D{{Key: "final_rank", Value: 1}}, limit)
+}
+
+//FindOf : find the records of a player, the lastest season first.
+func (m *SeasonRecordDAO) FindOf(deviceID string) ([]models.SeasonRecord, error) {
+	return m.find(bson.M{"device_id": deviceID}, bson.D{{Key: "created_time", Value: -1}}, 0)
+}
+
+func (m *SeasonRecordDAO) find(conditions bson.M, sort bson.D, limit int64) ([]models.SeasonRecord, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(sort)
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.SeasonRecord
+	for cur.Next(ctx) {
+		var elem models.SeasonRecord
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
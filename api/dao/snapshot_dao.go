@@ -0,0 +1,169 @@
+package dao
+
+import (
+	"context"
+	"earthshaker/api/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+//SnapshotDAO : the rank snapshots, one per player, replaced by every run of the snapshot job
+type SnapshotDAO struct {
+	c       *mongo.Collection
+	frozen  *mongo.Collection
+	timeOut time.Duration
+}
+
+//Setup : Set collection name
+func (m *SnapshotDAO) Setup() {
+	m.c = mgoDB.Collection("rank_snapshot")
+	m.frozen = mgoDB.Collection("rank_frozen")
+	m.timeOut = 3 * time.Second
+	ensureIndex(m.frozen, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "snapshot_time", Value: 1},
+			{Key: "player_mmr", Value: -1},
+			{Key: "_id", Value: 1},
+		},
+		Options: options.Index().SetName("snapshot_time_rank"),
+	})
+}
+
+//Freeze : copy the mmr of the players for the snapshot taken at the time.
+func (m *SnapshotDAO) Freeze(players []models.Status, snapshotTime time.Time) error {
+	if len(players) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	var writes []mongo.WriteModel
+	for _, player := range players {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": player.DeviceID}).
+			SetReplacement(models.FrozenRank{
+				DeviceID:     player.DeviceID,
+				PlayerNation: player.PlayerNation,
+				PlayerMMR:    player.PlayerMMR,
+				SnapshotTime: snapshotTime,
+			}).
+			SetUpsert(true))
+	}
+	_, err := m.frozen.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+//CountFrozen : the number of players copied for the snapshot taken at the time.
+func (m *SnapshotDAO) CountFrozen(snapshotTime time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	return m.frozen.CountDocuments(ctx, bson.M{"snapshot_time": snapshotTime})
+}
+
+//FindFrozenAfter : find the copies of the snapshot taken at the time after a copy in rank order, from the top if it is nil.
+func (m *SnapshotDAO) FindFrozenAfter(snapshotTime time.Time, after *models.FrozenRank, limit int64) ([]models.FrozenRank, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	conditions := bson.M{"snapshot_time": snapshotTime}
+	if after != nil {
+		conditions["$or"] = []bson.M{
+			bson.M{"player_mmr": bson.M{"$lt": after.PlayerMMR}},
+			bson.M{"player_mmr": after.PlayerMMR, "_id": bson.M{"$gt": after.DeviceID}},
+		}
+	}
+	findOptions := options.Find()
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.D{
+		{Key: "player_mmr", Value: -1},
+		{Key: "_id", Value: 1},
+	})
+	cur, err := m.frozen.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	var results []models.FrozenRank
+	for cur.Next(ctx) {
+		var elem models.FrozenRank
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	return results, cur.Err()
+}
+
+//DeleteFrozen : remove the copies of the snapshot taken at the time and of earlier runs.
+func (m *SnapshotDAO) DeleteFrozen(snapshotTime time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	_, err := m.frozen.DeleteMany(ctx, bson.M{"snapshot_time": bson.M{"$lte": snapshotTime}})
+	return err
+}
+
+//FindOf : find the snapshot of a player.
+func (m *SnapshotDAO) FindOf(deviceID string) (models.RankSnapshot, error) {
+	var snp models.RankSnapshot
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	err := m.c.FindOne(ctx, bson.M{"_id": deviceID}).Decode(&snp)
+	return snp, err
+}
+
+//SaveAll : replace the snapshots of the players.
+func (m *SnapshotDAO) SaveAll(snapshots []models.RankSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	var writes []mongo.WriteModel
+	for _, snapshot := range snapshots {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": snapshot.DeviceID}).
+			SetReplacement(snapshot).
+			SetUpsert(true))
+	}
+	_, err := m.c.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+//DeleteBefore : remove the snapshots of players missing from the run taken at the time.
+func (m *SnapshotDAO) DeleteBefore(snapshotTime time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	_, err := m.c.DeleteMany(ctx, bson.M{"snapshot_time": bson.M{"$lt": snapshotTime}})
+	return err
+}
+
+//RanksOf : the ranks of the players in the snapshot, players without a snapshot are left out.
+func (m *SnapshotDAO) RanksOf(deviceIDs []string) (map[string]int64, error) {
+	ranks := map[string]int64{}
+	if len(deviceIDs) == 0 {
+		return ranks, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetProjection(bson.M{
+		"rank": 1,
+	})
+	cur, err := m.c.Find(ctx, bson.M{"_id": bson.M{"$in": deviceIDs}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	for cur.Next(ctx) {
+		var elem models.RankSnapshot
+		if err := cur.Decode(&elem); err != nil {
+			return nil, err
+		}
+		ranks[elem.DeviceID] = elem.Rank
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return ranks, nil
+}
@@ -2,10 +2,14 @@ package dao
 
 import (
 	"context"
+	"earthshaker/api/helper"
 	"earthshaker/api/models"
+	"errors"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -25,6 +29,15 @@ const (
 func (m *StatusDAO) Setup() {
 	m.c = mgoDB.Collection("player_status")
 	m.timeOut = 3 * time.Second
+	// - The players are paged in rank order, among all players or in a nation
+	ensureIndex(m.c, mongo.IndexModel{
+		Keys:    bson.D{{Key: "player_mmr", Value: -1}, {Key: "device_id", Value: 1}},
+		Options: options.Index().SetName("leaderboard"),
+	})
+	ensureIndex(m.c, mongo.IndexModel{
+		Keys:    bson.D{{Key: "player_nation", Value: 1}, {Key: "player_mmr", Value: -1}, {Key: "device_id", Value: 1}},
+		Options: options.Index().SetName("nation_leaderboard"),
+	})
 }
 
 //Exist : check if the player is exist or not.
@@ -58,6 +71,8 @@ func (m *StatusDAO) FindByIDs(ids []string) ([]models.Status, error) {
 		"device_id":     1,
 		"player_name":   1,
 		"player_nation": 1,
+		"player_status": 1,
+		"updated_time":  1,
 	}
 	conditions := bson.M{
 		"device_id": bson.M{"$in": ids},
@@ -83,6 +98,17 @@ func (m *StatusDAO) FindByIDs(ids []string) ([]models.Status, error) {
 	return results, nil
 }
 
+//ExistName : check if another device has the name, compared by its name key.
+func (m *StatusDAO) ExistName(nameKey string, deviceID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	count, err := m.c.CountDocuments(ctx, bson.M{
+		"name_key":  nameKey,
+		"device_id": bson.M{"$ne": deviceID},
+	})
+	return count > 0, err
+}
+
 //Upsert : this is a comment
 func (m *StatusDAO) Upsert(stt models.Status) error {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
@@ -97,6 +123,7 @@ func (m *StatusDAO) Upsert(stt models.Status) error {
 		}
 		if len(stt.PlayerName) > 0 {
 			updateFields["player_name"] = stt.PlayerName
+			updateFields["name_key"] = stt.NameKey
 		}
 		if len(stt.PlayerStatus) > 0 {
 			updateFields["player_status"] = stt.PlayerStatus
@@ -104,12 +131,18 @@ func (m *StatusDAO) Upsert(stt models.Status) error {
 		if len(stt.PlayerNation) > 0 {
 			updateFields["player_nation"] = stt.PlayerNation
 		}
+		if len(stt.GameMode) > 0 {
+			updateFields["game_mode"] = stt.GameMode
+		}
 		_, err = m.c.UpdateOne(ctx, bson.M{"device_id": stt.DeviceID}, bson.M{"$set": updateFields})
 	} else {
 		stt.UpdatedTime = time.Now()
 		stt.CreatedTime = stt.UpdatedTime
 		_, err = m.c.InsertOne(ctx, stt)
 	}
+	if isDuplicateKey(err) {
+		return errors.New("NameTaken")
+	}
 	return err
 }
 
@@ -161,49 +194,274 @@ func (m *StatusDAO) FindAllWaitingPlayers() ([]models.Status, error) {
 	return results, nil
 }
 
-//CalculateRankOf :
+//CalculateRankOf : players are ranked by mmr, ties by device id, so every player has a distinct rank.
 func (m *StatusDAO) CalculateRankOf(deviceID string) (int64, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
-	defer cancel()
 	stt, err := m.FindByID(deviceID)
 	if err != nil {
 		return 0, nil
 	}
+	return m.RankIn(stt, "")
+}
+
+//RankIn : the rank of a player among the players of a nation, all players if it is empty.
+func (m *StatusDAO) RankIn(stt models.Status, nation string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
 	opts := options.Count().SetMaxTime(2 * time.Second)
-	conditions := bson.M{}
-	conditions["player_mmr"] = bson.M{
-		"$gt": stt.PlayerMMR,
-	}
-	num, err := m.c.CountDocuments(ctx, conditions, opts)
+	num, err := m.c.CountDocuments(ctx, inNation(rankedBefore(stt.PlayerMMR, stt.DeviceID), nation), opts)
 	return num + 1, err
 }
 
 //FindTopRank :
 func (m *StatusDAO) FindTopRank() (models.Status, error) {
+	var elem models.Status
+	players, err := m.FindRankedAfter(nil, "", 1)
+	if err != nil || len(players) == 0 {
+		return elem, err
+	}
+	return players[0], nil
+}
+
+//FindRankedAfter : find the players of a nation ranked after a player in rank order, from the top if it is nil.
+//An empty nation means all players.
+func (m *StatusDAO) FindRankedAfter(after *models.Status, nation string, limit int64) ([]models.Status, error) {
+	conditions := bson.M{}
+	if after != nil {
+		conditions = rankedAfter(after.PlayerMMR, after.DeviceID)
+	}
+	return m.findRanked(inNation(conditions, nation), bson.D{
+		{Key: "player_mmr", Value: -1},
+		{Key: "device_id", Value: 1},
+	}, limit)
+}
+
+//FindAfter : find the players after a device id in device id order, from the first if it is empty.
+//Unlike the rank order it does not move when the mmr of the players changes.
+func (m *StatusDAO) FindAfter(deviceID string, limit int64) ([]models.Status, error) {
+	conditions := bson.M{}
+	if len(deviceID) > 0 {
+		conditions["device_id"] = bson.M{"$gt": deviceID}
+	}
+	return m.findRanked(conditions, bson.D{{Key: "device_id", Value: 1}}, limit)
+}
+
+//FindRankedBefore : find the players of a nation ranked just before a player in rank order.
+func (m *StatusDAO) FindRankedBefore(before models.Status, nation string, limit int64) ([]models.Status, error) {
+	results, err := m.findRanked(inNation(rankedBefore(before.PlayerMMR, before.DeviceID), nation), bson.D{
+		{Key: "player_mmr", Value: 1},
+		{Key: "device_id", Value: -1},
+	}, limit)
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results, err
+}
+
+func (m *StatusDAO) findRanked(conditions bson.M, sort bson.D, limit int64) ([]models.Status, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
 	defer cancel()
 	findOptions := options.Find()
-	findOptions.SetLimit(1)
-	findOptions.SetSort(bson.M{
-		"player_mmr": -1,
-	})
-	var elem models.Status
-	cur, err := m.c.Find(ctx, bson.M{}, findOptions)
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(sort)
+	cur, err := m.c.Find(ctx, conditions, findOptions)
 	if err != nil {
-		return elem, err
+		return nil, err
+	}
+
+	var results []models.Status
+	for cur.Next(ctx) {
+		var elem models.Status
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
 	}
-	if !cur.Next(ctx) {
-		return elem, nil
+	if err := cur.Err(); err != nil {
+		cur.Close(ctx)
+		return nil, err
 	}
-	err = cur.Decode(&elem)
+	cur.Close(ctx)
+	return results, nil
+}
+
+//AggregateNations : the number of players, active players since a time and the average mmr of every nation.
+func (m *StatusDAO) AggregateNations(activeSince time.Time) ([]models.NationStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	cur, err := m.c.Aggregate(ctx, mongo.Pipeline{
+		bson.D{{Key: "$match", Value: bson.M{
+			"player_nation": bson.M{"$exists": true, "$ne": ""},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":         "$player_nation",
+			"players":     bson.M{"$sum": 1},
+			"average_mmr": bson.M{"$avg": "$player_mmr"},
+			"active_players": bson.M{"$sum": bson.M{
+				"$cond": []interface{}{bson.M{"$gte": []interface{}{"$updated_time", activeSince}}, 1, 0},
+			}},
+		}}},
+	})
 	if err != nil {
+		return nil, err
+	}
+
+	var results []models.NationStats
+	for cur.Next(ctx) {
+		var elem models.NationStats
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
+	}
+	if err := cur.Err(); err != nil {
 		cur.Close(ctx)
-		return elem, err
+		return nil, err
+	}
+	cur.Close(ctx)
+	return results, nil
+}
+
+//BackfillMMR : players saved before mmr was always stored have none, they start at zero.
+func (m *StatusDAO) BackfillMMR() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	_, err := m.c.UpdateMany(ctx, bson.M{
+		"player_mmr": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"player_mmr": 0},
+	})
+	return err
+}
+
+//BackfillNameKey : players named before names had a key have none, it is derived from their name.
+func (m *StatusDAO) BackfillNameKey() error {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	cursor, err := m.c.Find(ctx, bson.M{
+		"player_name": bson.M{"$exists": true},
+		"name_key":    bson.M{"$exists": false},
+	}, options.Find().SetProjection(bson.M{"device_id": 1, "player_name": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var stt models.Status
+		if err := cursor.Decode(&stt); err != nil {
+			return err
+		}
+		_, err := m.c.UpdateOne(ctx, bson.M{
+			"device_id": stt.DeviceID,
+			"name_key":  bson.M{"$exists": false},
+		}, bson.M{
+			"$set": bson.M{"name_key": helper.NameKey(stt.PlayerName)},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+//EnsureUniqueNames : a unique index on the non-empty name keys, so two players can not save the same name at once.
+//It fails while players share a name.
+func (m *StatusDAO) EnsureUniqueNames() error {
+	ctx, cancel := context.WithTimeout(context.Background(), AggregateTimeOut)
+	defer cancel()
+	_, err := m.c.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"name_key": 1},
+		Options: options.Index().
+			SetName("name_key_unique").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"name_key": bson.M{"$gt": ""}}),
+	})
+	return err
+}
+
+func inNation(conditions bson.M, nation string) bson.M {
+	if len(nation) > 0 {
+		conditions["player_nation"] = nation
+	}
+	return conditions
+}
+
+func rankedBefore(mmr int64, deviceID string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			bson.M{"player_mmr": bson.M{"$gt": mmr}},
+			bson.M{"player_mmr": mmr, "device_id": bson.M{"$lt": deviceID}},
+		},
+	}
+}
+
+func rankedAfter(mmr int64, deviceID string) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			bson.M{"player_mmr": bson.M{"$lt": mmr}},
+			bson.M{"player_mmr": mmr, "device_id": bson.M{"$gt": deviceID}},
+		},
+	}
+}
+
+//Search : find players by device id, a part of their name and status, empty values match all.
+func (m *StatusDAO) Search(deviceID string, name string, status string, skip int64, limit int64) ([]models.Status, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	findOptions := options.Find()
+	findOptions.SetSkip(skip)
+	findOptions.SetLimit(limit)
+	findOptions.SetSort(bson.M{
+		"updated_time": -1,
+	})
+	conditions := bson.M{}
+	if len(deviceID) > 0 {
+		conditions["device_id"] = deviceID
+	}
+	if len(name) > 0 {
+		conditions["player_name"] = primitive.Regex{Pattern: regexp.QuoteMeta(name), Options: "i"}
+	}
+	if len(status) > 0 {
+		conditions["player_status"] = status
+	}
+	cur, err := m.c.Find(ctx, conditions, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []models.Status
+	for cur.Next(ctx) {
+		var elem models.Status
+		err := cur.Decode(&elem)
+		if err != nil {
+			cur.Close(ctx)
+			return nil, err
+		}
+		results = append(results, elem)
 	}
 	if err := cur.Err(); err != nil {
 		cur.Close(ctx)
-		return elem, err
+		return nil, err
 	}
 	cur.Close(ctx)
-	return elem, nil
+	return results, nil
+}
+
+//AdjustMMR : add delta to the mmr of a player, returns the player after update.
+func (m *StatusDAO) AdjustMMR(deviceID string, delta int64) (models.Status, error) {
+	var stt models.Status
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeOut)
+	defer cancel()
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := m.c.FindOneAndUpdate(ctx, bson.M{"device_id": deviceID}, bson.M{
+		"$inc": bson.M{"player_mmr": delta},
+		"$set": bson.M{"updated_time": time.Now()},
+	}, opts).Decode(&stt)
+	if err == mongo.ErrNoDocuments {
+		return stt, errors.New("NotFound")
+	}
+	return stt, err
 }
@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+//LinkCodeLength : number of characters of a link code
+const LinkCodeLength = 8
+
+//linkCodeAlphabet : the characters of a link code, without the ones easy to mistake for each other
+const linkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+//NewLinkCode : a random link code.
+func NewLinkCode() (string, error) {
+	var code strings.Builder
+	max := big.NewInt(int64(len(linkCodeAlphabet)))
+	for i := 0; i < LinkCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(linkCodeAlphabet[n.Int64()])
+	}
+	return code.String(), nil
+}
+
+//NormalizeLinkCode : a link code as it is stored, whatever the case and separators typed.
+func NormalizeLinkCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.Replace(code, "-", "", -1)
+	code = strings.Replace(code, " ", "", -1)
+	return code
+}
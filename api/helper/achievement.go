@@ -0,0 +1,21 @@
+package helper
+
+import (
+	"earthshaker/api/models"
+)
+
+//AchievementMet : check the progress of a player against the metric and threshold of an achievement.
+//For BeatRank the threshold is the worst rank of a beaten opponent, for the other metrics the minimum value.
+func AchievementMet(metric string, threshold int64, progress models.Progress) bool {
+	switch metric {
+	case models.METRICWINS:
+		return progress.Wins >= threshold
+	case models.METRICBESTSTREAK:
+		return progress.BestStreak >= threshold
+	case models.METRICNATIONSPLAYED:
+		return progress.NationsPlayed >= threshold
+	case models.METRICBEATRANK:
+		return progress.BeatRank > 0 && progress.BeatRank <= threshold
+	}
+	return false
+}
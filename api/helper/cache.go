@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"sync"
+	"time"
+)
+
+//ValueCache : a value computed again when it is older than its ttl.
+type ValueCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	value      interface{}
+	loadedTime time.Time
+}
+
+//NewValueCache :
+func NewValueCache(ttl time.Duration) *ValueCache {
+	return &ValueCache{
+		ttl: ttl,
+	}
+}
+
+//Get : the cached value and when it was loaded, it is loaded again if it is stale.
+func (c *ValueCache) Get(now time.Time, load func() (interface{}, error)) (interface{}, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.value != nil && now.Sub(c.loadedTime) < c.ttl {
+		return c.value, c.loadedTime, nil
+	}
+	value, err := load()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	c.value = value
+	c.loadedTime = now
+	return c.value, c.loadedTime, nil
+}
@@ -0,0 +1,60 @@
+package helper
+
+import (
+	"sort"
+	"time"
+
+	"earthshaker/api/models"
+)
+
+//MatchClock : clocks of a started match.
+type MatchClock struct {
+	ToMoveID  string
+	TurnStart time.Time
+	Used      map[string]time.Duration
+}
+
+//ClockOf : find who is to move, since when, and the time each player has used so far.
+func ClockOf(mch models.Match) MatchClock {
+	clock := MatchClock{
+		ToMoveID:  mch.FirstTurnID,
+		TurnStart: mch.StartedTime,
+		Used:      map[string]time.Duration{},
+	}
+	if clock.TurnStart.IsZero() {
+		clock.TurnStart = mch.UpdatedTime
+	}
+	moves := make([]models.Move, len(mch.Moves))
+	copy(moves, mch.Moves)
+	sort.SliceStable(moves, func(i, j int) bool {
+		return moves[i].Sequence < moves[j].Sequence
+	})
+	for _, mv := range moves {
+		if !mv.CreatedTime.IsZero() {
+			if mv.CreatedTime.After(clock.TurnStart) {
+				clock.Used[mv.DeviceID] += mv.CreatedTime.Sub(clock.TurnStart)
+			}
+			clock.TurnStart = mv.CreatedTime
+		}
+		if clock.ToMoveID = mch.Device1ID; mv.DeviceID == mch.Device1ID {
+			clock.ToMoveID = mch.Device2ID
+		}
+	}
+	return clock
+}
+
+//TimedOutPlayer : the player whose turn or match clock has run out at now, empty if none.
+func TimedOutPlayer(mch models.Match, turnLimit time.Duration, matchLimit time.Duration, now time.Time) string {
+	clock := ClockOf(mch)
+	if len(clock.ToMoveID) == 0 || clock.TurnStart.IsZero() {
+		return ""
+	}
+	elapsed := now.Sub(clock.TurnStart)
+	if turnLimit > 0 && elapsed > turnLimit {
+		return clock.ToMoveID
+	}
+	if matchLimit > 0 && clock.Used[clock.ToMoveID]+elapsed > matchLimit {
+		return clock.ToMoveID
+	}
+	return ""
+}
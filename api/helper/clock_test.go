@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"earthshaker/api/models"
+)
+
+func TestClockOf(t *testing.T) {
+	started := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return started.Add(time.Duration(seconds) * time.Second)
+	}
+	tests := []struct {
+		name      string
+		mch       models.Match
+		toMoveID  string
+		turnStart time.Time
+		used      map[string]time.Duration
+	}{
+		{
+			name:      "no moves",
+			mch:       models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "b", StartedTime: started},
+			toMoveID:  "b",
+			turnStart: started,
+			used:      map[string]time.Duration{},
+		},
+		{
+			name:      "not started falls back to the updated time",
+			mch:       models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "a", UpdatedTime: at(5)},
+			toMoveID:  "a",
+			turnStart: at(5),
+			used:      map[string]time.Duration{},
+		},
+		{
+			name: "moves out of order are sorted by sequence",
+			mch: models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "b", StartedTime: started, Moves: []models.Move{
+				{DeviceID: "a", Sequence: 2, CreatedTime: at(30)},
+				{DeviceID: "b", Sequence: 1, CreatedTime: at(10)},
+			}},
+			toMoveID:  "b",
+			turnStart: at(30),
+			used:      map[string]time.Duration{"b": 10 * time.Second, "a": 20 * time.Second},
+		},
+		{
+			name: "a move without a time keeps the turn start",
+			mch: models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "a", StartedTime: started, Moves: []models.Move{
+				{DeviceID: "a", Sequence: 1},
+			}},
+			toMoveID:  "b",
+			turnStart: started,
+			used:      map[string]time.Duration{},
+		},
+		{
+			name: "a move before the turn start uses no time",
+			mch: models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "a", StartedTime: at(10), Moves: []models.Move{
+				{DeviceID: "a", Sequence: 1, CreatedTime: at(5)},
+			}},
+			toMoveID:  "b",
+			turnStart: at(5),
+			used:      map[string]time.Duration{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := ClockOf(tt.mch)
+			if clock.ToMoveID != tt.toMoveID {
+				t.Errorf("ToMoveID = %q, want %q", clock.ToMoveID, tt.toMoveID)
+			}
+			if !clock.TurnStart.Equal(tt.turnStart) {
+				t.Errorf("TurnStart = %v, want %v", clock.TurnStart, tt.turnStart)
+			}
+			if len(clock.Used) != len(tt.used) {
+				t.Errorf("Used = %v, want %v", clock.Used, tt.used)
+			}
+			for deviceID, used := range tt.used {
+				if clock.Used[deviceID] != used {
+					t.Errorf("Used[%q] = %v, want %v", deviceID, clock.Used[deviceID], used)
+				}
+			}
+		})
+	}
+}
+
+func TestTimedOutPlayer(t *testing.T) {
+	started := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return started.Add(time.Duration(seconds) * time.Second)
+	}
+	played := models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "a", StartedTime: started, Moves: []models.Move{
+		{DeviceID: "a", Sequence: 1, CreatedTime: at(50)},
+		{DeviceID: "b", Sequence: 2, CreatedTime: at(60)},
+	}}
+	tests := []struct {
+		name       string
+		mch        models.Match
+		turnLimit  time.Duration
+		matchLimit time.Duration
+		now        time.Time
+		want       string
+	}{
+		{"turn clock at the limit", played, 30 * time.Second, 0, at(90), ""},
+		{"turn clock past the limit", played, 30 * time.Second, 0, at(91), "a"},
+		{"match clock at the limit", played, 0, 80 * time.Second, at(90), ""},
+		{"match clock past the limit", played, 0, 80 * time.Second, at(91), "a"},
+		{"match clock counts the earlier turns", played, 0, 79 * time.Second, at(80), ""},
+		{"match clock past the limit with the earlier turns", played, 0, 79 * time.Second, at(90), "a"},
+		{"no limits", played, 0, 0, at(3600), ""},
+		{"nobody to move", models.Match{StartedTime: started}, time.Second, 0, at(10), ""},
+		{"no turn start", models.Match{Device1ID: "a", Device2ID: "b", FirstTurnID: "a"}, time.Second, 0, at(10), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TimedOutPlayer(tt.mch, tt.turnLimit, tt.matchLimit, tt.now); got != tt.want {
+				t.Errorf("TimedOutPlayer() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
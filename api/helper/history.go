@@ -0,0 +1,38 @@
+package helper
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//EncodeHistoryCursor : an opaque cursor after a match of the history.
+func EncodeHistoryCursor(createdTime time.Time, matchID primitive.ObjectID) string {
+	raw := strconv.FormatInt(createdTime.UnixNano()/int64(time.Millisecond), 10) + ":" + matchID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+//DecodeHistoryCursor : the creation time and id of the match of a cursor.
+func DecodeHistoryCursor(cursor string) (time.Time, primitive.ObjectID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, errors.New("InvalidCursor")
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return time.Time{}, primitive.NilObjectID, errors.New("InvalidCursor")
+	}
+	millis, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, errors.New("InvalidCursor")
+	}
+	matchID, err := primitive.ObjectIDFromHex(parts[1])
+	if err != nil {
+		return time.Time{}, primitive.NilObjectID, errors.New("InvalidCursor")
+	}
+	return time.Unix(0, millis*int64(time.Millisecond)), matchID, nil
+}
@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"crypto/subtle"
+	"sync"
+	"time"
+
+	"earthshaker/api/models"
+)
+
+//KeySet : the app keys that can be accepted, safe to replace while in use.
+type KeySet struct {
+	mu   sync.RWMutex
+	keys []models.APIKey
+}
+
+//Replace : replace all keys of the set
+func (k *KeySet) Replace(keys []models.APIKey) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = keys
+}
+
+//Match : find the id of a key entry that is valid at now, false with the id of a matching invalid entry if there is none.
+func (k *KeySet) Match(key string, now time.Time) (string, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(key) == 0 {
+		return "", false
+	}
+	// - The same key can be issued again under another id, any valid entry accepts it
+	invalidID := ""
+	for _, apiKey := range k.keys {
+		if subtle.ConstantTimeCompare([]byte(apiKey.Key), []byte(key)) != 1 {
+			continue
+		}
+		if apiKey.Revoked ||
+			(!apiKey.NotBefore.IsZero() && now.Before(apiKey.NotBefore)) ||
+			(!apiKey.NotAfter.IsZero() && !now.Before(apiKey.NotAfter)) {
+			if len(invalidID) == 0 {
+				invalidID = apiKey.KeyID
+			}
+			continue
+		}
+		return apiKey.KeyID, true
+	}
+	return invalidID, false
+}
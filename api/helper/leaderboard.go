@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+//EncodeRankCursor : an opaque cursor after the player at the rank.
+func EncodeRankCursor(mmr int64, deviceID string, rank int64) string {
+	raw := strconv.FormatInt(mmr, 10) + ":" + strconv.FormatInt(rank, 10) + ":" + deviceID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+//DecodeRankCursor : the mmr, device id and rank of the player of a cursor.
+func DecodeRankCursor(cursor string) (int64, string, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", 0, errors.New("InvalidCursor")
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 || len(parts[2]) == 0 {
+		return 0, "", 0, errors.New("InvalidCursor")
+	}
+	mmr, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", 0, errors.New("InvalidCursor")
+	}
+	rank, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || rank < 1 {
+		return 0, "", 0, errors.New("InvalidCursor")
+	}
+	return mmr, parts[2], rank, nil
+}
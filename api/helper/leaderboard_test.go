@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestDecodeRankCursor(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name     string
+		cursor   string
+		mmr      int64
+		deviceID string
+		rank     int64
+		valid    bool
+	}{
+		{"an encoded cursor", EncodeRankCursor(1500, "device-1", 42), 1500, "device-1", 42, true},
+		{"a negative mmr", EncodeRankCursor(-20, "device-1", 7), -20, "device-1", 7, true},
+		{"a device id with a colon", EncodeRankCursor(1500, "a:b", 1), 1500, "a:b", 1, true},
+		{"not base64", "!!!", 0, "", 0, false},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("1500:1:d")), 0, "", 0, false},
+		{"missing parts", encode("1500:1"), 0, "", 0, false},
+		{"an empty device id", encode("1500:1:"), 0, "", 0, false},
+		{"an invalid mmr", encode("x:1:d"), 0, "", 0, false},
+		{"an invalid rank", encode("1500:x:d"), 0, "", 0, false},
+		{"a zero rank", encode("1500:0:d"), 0, "", 0, false},
+		{"an empty cursor", "", 0, "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mmr, deviceID, rank, err := DecodeRankCursor(tt.cursor)
+			if !tt.valid {
+				if err == nil || err.Error() != "InvalidCursor" {
+					t.Fatalf("DecodeRankCursor(%q) error = %v, want InvalidCursor", tt.cursor, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeRankCursor(%q) error = %v", tt.cursor, err)
+			}
+			if mmr != tt.mmr || deviceID != tt.deviceID || rank != tt.rank {
+				t.Errorf("DecodeRankCursor(%q) = %d, %q, %d, want %d, %q, %d", tt.cursor, mmr, deviceID, rank, tt.mmr, tt.deviceID, tt.rank)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//NAMETOOSHORT : player name errors
+const (
+	NAMETOOSHORT    = "TooShort"
+	NAMETOOLONG     = "TooLong"
+	NAMEINVALIDCHAR = "InvalidCharacter"
+	NAMESPACING     = "InvalidSpacing"
+	NAMEDENIED      = "Denied"
+	NAMETAKEN       = "Taken"
+)
+
+//NameError : why a player name is rejected
+type NameError struct {
+	Code   string
+	Detail string
+}
+
+func (e *NameError) Error() string {
+	return e.Code + ": " + e.Detail
+}
+
+//leetOf : digits and symbols used for letters
+var leetOf = map[rune]rune{
+	'0': 'o', '1': 'i', '2': 'z', '3': 'e', '4': 'a', '5': 's', '6': 'g', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'i', '+': 't',
+}
+
+//confusableOf : non-latin letters looking like latin ones
+var confusableOf = map[rune]rune{
+	'а': 'a', 'в': 'b', 'с': 'c', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x', 'ω': 'w', 'ı': 'i', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ß': 's',
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ç': 'c', 'è': 'e', 'é': 'e', 'ê': 'e',
+	'ë': 'e', 'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ñ': 'n', 'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o',
+	'ö': 'o', 'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y',
+}
+
+//ValidateName : check the length, characters and spacing of a player name and the denylist.
+//Names are letters and digits of any script, separated by single spaces, dots, dashes or underscores.
+func ValidateName(name string, minLength int, maxLength int, denylist []string) *NameError {
+	length := utf8.RuneCountInString(name)
+	if length < minLength {
+		return &NameError{Code: NAMETOOSHORT, Detail: "Name is shorter than the minimum length"}
+	}
+	if maxLength > 0 && length > maxLength {
+		return &NameError{Code: NAMETOOLONG, Detail: "Name is longer than the maximum length"}
+	}
+	var last rune
+	for i, c := range name {
+		switch {
+		case unicode.IsLetter(c), unicode.IsDigit(c):
+		case unicode.Is(unicode.Mn, c):
+			if i == 0 || !unicode.IsLetter(last) && !unicode.Is(unicode.Mn, last) {
+				return &NameError{Code: NAMEINVALIDCHAR, Detail: "A mark must follow a letter"}
+			}
+		case c == ' ' || c == '.' || c == '-' || c == '_':
+			if i == 0 || i == len(name)-1 || isSeparator(last) {
+				return &NameError{Code: NAMESPACING, Detail: "Separators must be single and inside the name"}
+			}
+		default:
+			return &NameError{Code: NAMEINVALIDCHAR, Detail: "Character " + strconv.QuoteRune(c) + " is not allowed"}
+		}
+		last = c
+	}
+	if deniedName(name, denylist) {
+		return &NameError{Code: NAMEDENIED, Detail: "Name is not allowed"}
+	}
+	return nil
+}
+
+//deniedName : whether a denylist word is a word of the name or the whole name, compared in normalized form.
+//A word starting with "*" is denied anywhere inside the name as well.
+func deniedName(name string, denylist []string) bool {
+	candidates := []string{NormalizeName(name)}
+	for _, token := range strings.FieldsFunc(name, isSeparator) {
+		candidates = append(candidates, NormalizeName(token))
+	}
+	for _, word := range denylist {
+		anywhere := strings.HasPrefix(word, "*")
+		word = NormalizeName(strings.TrimPrefix(word, "*"))
+		if len(word) == 0 {
+			continue
+		}
+		for _, candidate := range candidates {
+			if anywhere && (strings.Contains(candidate, word) || strings.Contains(collapseRepeats(candidate), collapseRepeats(word))) {
+				return true
+			}
+			if !anywhere && (candidate == word || collapseRepeats(candidate) == collapseRepeats(word)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//NormalizeName : the comparable form of a name, lower case latin letters and digits without separators and marks,
+//with leetspeak and confusable letters replaced, used against the denylist.
+func NormalizeName(name string) string {
+	return foldName(name, true)
+}
+
+//NameKey : the form of a name compared for uniqueness, folded like NormalizeName but keeping digits as they are,
+//so names only differing by digits are different names.
+func NameKey(name string) string {
+	return foldName(name, false)
+}
+
+func foldName(name string, leet bool) string {
+	var b strings.Builder
+	for _, c := range name {
+		if c >= 0xFF01 && c <= 0xFF5E {
+			c -= 0xFEE0
+		}
+		c = unicode.ToLower(c)
+		if r, exist := leetOf[c]; exist && leet {
+			c = r
+		} else if r, exist := confusableOf[c]; exist {
+			c = r
+		}
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func collapseRepeats(s string) string {
+	var b strings.Builder
+	var last rune
+	for _, c := range s {
+		if c != last {
+			b.WriteRune(c)
+		}
+		last = c
+	}
+	return b.String()
+}
+
+func isSeparator(c rune) bool {
+	return c == ' ' || c == '.' || c == '-' || c == '_'
+}
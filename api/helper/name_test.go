@@ -0,0 +1,93 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestValidateName(t *testing.T) {
+	denylist := []string{"admin", "*shit"}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sam", ""},
+		{"Sam6", ""},
+		{"Jo", NAMETOOSHORT},
+		{"Averyveryverylongname", NAMETOOLONG},
+		{"Sam Lee", ""},
+		{"Sam.Lee-9_x", ""},
+		{"Sam  Lee", NAMESPACING},
+		{" Sam", NAMESPACING},
+		{"Sam_", NAMESPACING},
+		{"Sam!", NAMEINVALIDCHAR},
+		{"Zo\u00eb", ""},
+		{"Zoe\u0308", ""},
+		{"\u0308Zoe", NAMEINVALIDCHAR},
+		{"김민준", ""},
+		{"Admin", NAMEDENIED},
+		{"4dm1n", NAMEDENIED},
+		{"Aaadmin", NAMEDENIED},
+		{"The Admin", NAMEDENIED},
+		{"Admins", ""},
+		{"Badminton", ""},
+		{"Bullsh1tter", NAMEDENIED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.name, 3, 16, denylist)
+			if len(tt.want) == 0 && err != nil {
+				t.Fatalf("ValidateName(%q) = %v, want nil", tt.name, err)
+			}
+			if len(tt.want) > 0 && (err == nil || err.Code != tt.want) {
+				t.Fatalf("ValidateName(%q) = %v, want %s", tt.name, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Sam Lee", "samlee"},
+		{"S4m", "sam"},
+		{"$@m", "sam"},
+		{"Ｓａｍ", "sam"},
+		{"Ѕаm", "sam"},
+		{"Zoë", "zoe"},
+		{"Player1", "playeri"},
+		{"Playeri", "playeri"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.name); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name1 string
+		name2 string
+		same  bool
+	}{
+		{"Sam6", "Sam9", false},
+		{"Player1", "Playeri", false},
+		{"S4m", "Sam", false},
+		{"Sam Lee", "sam.lee", true},
+		{"Ｓａｍ", "sam", true},
+		{"Ѕаm", "Sam", true},
+		{"Zoë", "Zoe", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name1+"/"+tt.name2, func(t *testing.T) {
+			key1, key2 := NameKey(tt.name1), NameKey(tt.name2)
+			if (key1 == key2) != tt.same {
+				t.Errorf("NameKey(%q) = %q, NameKey(%q) = %q, want same %v", tt.name1, key1, tt.name2, key2, tt.same)
+			}
+		})
+	}
+}
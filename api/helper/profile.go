@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"earthshaker/api/models"
+	"unicode"
+	"unicode/utf8"
+)
+
+//PROFILEUNKNOWN : player profile errors
+const (
+	PROFILEUNKNOWN     = "Unknown"
+	PROFILELOCKED      = "Locked"
+	PROFILEINVALID     = "Invalid"
+	PROFILETOOLONG     = "TooLong"
+	PROFILEINVALIDCHAR = "InvalidCharacter"
+)
+
+//ProfileError : why a field of a player profile is rejected
+type ProfileError struct {
+	Field  string
+	Code   string
+	Detail string
+}
+
+func (e *ProfileError) Error() string {
+	return e.Field + ": " + e.Code + ": " + e.Detail
+}
+
+//ValidateProfile : check the fields of a profile, empty fields are not set.
+//The titles map the known title ids to whether the player unlocked them,
+//the nation is an ISO 3166-1 alpha-2 code.
+func ValidateProfile(profile models.Profile, avatars []string, titles map[string]bool, bioMaxLength int) *ProfileError {
+	if len(profile.AvatarID) > 0 && !contains(avatars, profile.AvatarID) {
+		return &ProfileError{Field: "avatar_id", Code: PROFILEUNKNOWN, Detail: "Avatar is not available"}
+	}
+	if len(profile.Title) > 0 {
+		unlocked, exist := titles[profile.Title]
+		if !exist {
+			return &ProfileError{Field: "title", Code: PROFILEUNKNOWN, Detail: "Title is not available"}
+		}
+		if !unlocked {
+			return &ProfileError{Field: "title", Code: PROFILELOCKED, Detail: "Title is not unlocked"}
+		}
+	}
+	if len(profile.PlayerNation) > 0 && !isNationCode(profile.PlayerNation) {
+		return &ProfileError{Field: "player_nation", Code: PROFILEINVALID, Detail: "Nation must be a two letter country code"}
+	}
+	if bioMaxLength > 0 && utf8.RuneCountInString(profile.Bio) > bioMaxLength {
+		return &ProfileError{Field: "bio", Code: PROFILETOOLONG, Detail: "Bio is longer than the maximum length"}
+	}
+	if !utf8.ValidString(profile.Bio) {
+		return &ProfileError{Field: "bio", Code: PROFILEINVALIDCHAR, Detail: "Bio is not valid UTF-8"}
+	}
+	for _, c := range profile.Bio {
+		if c != '\n' && (unicode.IsControl(c) || unicode.Is(unicode.Cf, c)) {
+			return &ProfileError{Field: "bio", Code: PROFILEINVALIDCHAR, Detail: "Bio has control characters"}
+		}
+	}
+	return nil
+}
+
+func isNationCode(nation string) bool {
+	if len(nation) != 2 {
+		return false
+	}
+	for _, c := range nation {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
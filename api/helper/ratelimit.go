@@ -0,0 +1,76 @@
+package helper
+
+import (
+	"earthshaker/api/models"
+	"math"
+	"sync"
+	"time"
+)
+
+//RateStore : where the token buckets are kept, in memory or shared between instances.
+type RateStore interface {
+	Take(key string, rate float64, burst int64, now time.Time) (bool, time.Duration, error)
+}
+
+//TakeToken : refill the bucket at rate tokens per second up to burst, then take one token.
+//If there is none left, it returns false and how long until the next token.
+func TakeToken(bucket *models.RateBucket, rate float64, burst int64, now time.Time) (bool, time.Duration) {
+	if bucket.UpdatedTime.IsZero() {
+		bucket.Tokens = float64(burst)
+	} else if elapsed := now.Sub(bucket.UpdatedTime); elapsed > 0 {
+		bucket.Tokens = math.Min(float64(burst), bucket.Tokens+elapsed.Seconds()*rate)
+	}
+	bucket.UpdatedTime = now
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		return true, 0
+	}
+	if rate <= 0 {
+		return false, time.Hour
+	}
+	return false, time.Duration((1 - bucket.Tokens) / rate * float64(time.Second))
+}
+
+//BucketIdleTime : how long until an unused bucket is full again and can be dropped.
+func BucketIdleTime(rate float64, burst int64) time.Duration {
+	if rate <= 0 {
+		return time.Hour
+	}
+	return time.Duration(float64(burst) / rate * float64(time.Second))
+}
+
+//MemoryRateStore : token buckets of a single instance.
+type MemoryRateStore struct {
+	mu      sync.Mutex
+	buckets map[string]*models.RateBucket
+	purged  time.Time
+}
+
+//NewMemoryRateStore :
+func NewMemoryRateStore() *MemoryRateStore {
+	return &MemoryRateStore{
+		buckets: map[string]*models.RateBucket{},
+	}
+}
+
+//Take : take a token from the bucket of the key.
+func (s *MemoryRateStore) Take(key string, rate float64, burst int64, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.purged) > time.Minute {
+		for k, bucket := range s.buckets {
+			if now.After(bucket.ExpiredTime) {
+				delete(s.buckets, k)
+			}
+		}
+		s.purged = now
+	}
+	bucket, exist := s.buckets[key]
+	if !exist {
+		bucket = &models.RateBucket{Key: key}
+		s.buckets[key] = bucket
+	}
+	ok, retryAfter := TakeToken(bucket, rate, burst, now)
+	bucket.ExpiredTime = now.Add(BucketIdleTime(rate, burst))
+	return ok, retryAfter, nil
+}
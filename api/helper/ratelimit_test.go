@@ -0,0 +1,78 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"earthshaker/api/models"
+)
+
+func TestTakeToken(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		bucket     models.RateBucket
+		rate       float64
+		burst      int64
+		now        time.Time
+		ok         bool
+		retryAfter time.Duration
+		tokens     float64
+	}{
+		{"a new bucket starts full", models.RateBucket{}, 1, 5, now, true, 0, 4},
+		{"an empty bucket waits for the next token", models.RateBucket{UpdatedTime: now}, 2, 5, now, false, 500 * time.Millisecond, 0},
+		{"a part of a token shortens the wait", models.RateBucket{Tokens: 0.75, UpdatedTime: now}, 1, 5, now, false, 250 * time.Millisecond, 0.75},
+		{"the bucket refills with the elapsed time", models.RateBucket{UpdatedTime: now}, 2, 5, now.Add(time.Second), true, 0, 1},
+		{"the refill stops at the burst", models.RateBucket{Tokens: 1, UpdatedTime: now}, 1, 3, now.Add(time.Hour), true, 0, 2},
+		{"a clock going back refills nothing", models.RateBucket{Tokens: 0.5, UpdatedTime: now}, 1, 3, now.Add(-time.Second), false, 500 * time.Millisecond, 0.5},
+		{"no rate never refills", models.RateBucket{UpdatedTime: now}, 0, 3, now.Add(time.Hour), false, time.Hour, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := tt.bucket
+			ok, retryAfter := TakeToken(&bucket, tt.rate, tt.burst, tt.now)
+			if ok != tt.ok || retryAfter != tt.retryAfter {
+				t.Errorf("TakeToken() = %v, %v, want %v, %v", ok, retryAfter, tt.ok, tt.retryAfter)
+			}
+			if bucket.Tokens != tt.tokens {
+				t.Errorf("Tokens = %v, want %v", bucket.Tokens, tt.tokens)
+			}
+			if !bucket.UpdatedTime.Equal(tt.now) {
+				t.Errorf("UpdatedTime = %v, want %v", bucket.UpdatedTime, tt.now)
+			}
+		})
+	}
+}
+
+func TestTakeTokenBurst(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var bucket models.RateBucket
+	for i := 0; i < 3; i++ {
+		if ok, _ := TakeToken(&bucket, 1, 3, now); !ok {
+			t.Fatalf("token %d of the burst is refused", i+1)
+		}
+	}
+	if ok, retryAfter := TakeToken(&bucket, 1, 3, now); ok || retryAfter != time.Second {
+		t.Fatalf("TakeToken() after the burst = %v, %v, want false, %v", ok, retryAfter, time.Second)
+	}
+	if ok, _ := TakeToken(&bucket, 1, 3, now.Add(time.Second)); !ok {
+		t.Fatal("the token refilled after a second is refused")
+	}
+}
+
+func TestMemoryRateStore(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryRateStore()
+	if ok, _, _ := store.Take("a", 1, 1, now); !ok {
+		t.Fatal("the first token of a is refused")
+	}
+	if ok, _, _ := store.Take("a", 1, 1, now); ok {
+		t.Fatal("the second token of a is taken")
+	}
+	if ok, _, _ := store.Take("b", 1, 1, now); !ok {
+		t.Fatal("the bucket of b is shared with a")
+	}
+	if ok, _, _ := store.Take("a", 1, 1, now.Add(2*time.Minute)); !ok {
+		t.Fatal("the purged bucket of a is not full again")
+	}
+}
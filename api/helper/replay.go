@@ -0,0 +1,64 @@
+package helper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"earthshaker/api/models"
+)
+
+//ReplayContentType : content type of a JSON Lines replay file
+const ReplayContentType = "application/x-ndjson"
+
+//WriteReplay : write a match as JSON Lines, the match header on the first line then one move per line.
+func WriteReplay(w io.Writer, mch models.Match) error {
+	enc := json.NewEncoder(w)
+	header := mch
+	header.Moves = nil
+	header.WebRTCMessages = nil
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	for _, mv := range mch.Moves {
+		if err := enc.Encode(mv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//ReadReplay : read a match written by WriteReplay.
+func ReadReplay(r io.Reader) (models.Match, error) {
+	var mch models.Match
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return mch, err
+		}
+		return mch, errors.New("EmptyReplay")
+	}
+	if err := json.Unmarshal(scanner.Bytes(), &mch); err != nil {
+		return mch, err
+	}
+	mch.Moves = nil
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var mv models.Move
+		if err := json.Unmarshal(scanner.Bytes(), &mv); err != nil {
+			return mch, err
+		}
+		mch.Moves = append(mch.Moves, mv)
+	}
+	if err := scanner.Err(); err != nil {
+		return mch, err
+	}
+	if mch.ID.IsZero() {
+		return mch, errors.New("InvalidReplay")
+	}
+	return mch, nil
+}
@@ -0,0 +1,37 @@
+package helper
+
+import "earthshaker/api/models"
+
+//ResolveMatch : set the final status of a match from the results reported by its players, returns the winner to reward, empty if none.
+func ResolveMatch(match *models.Match) string {
+	if match.MatchStatus == models.WAIT || match.MatchStatus == models.INIT {
+		match.MatchStatus = models.ERR
+		return ""
+	}
+	if len(match.WinnerID) > 0 && len(match.LoserID) > 0 && match.WinnerID != match.LoserID {
+		match.MatchStatus = models.END
+		return match.WinnerID
+	} else if len(match.WinnerID) == 0 && len(match.LoserID) > 0 {
+		if match.LoserID == match.Device1ID {
+			match.WinnerID = match.Device2ID
+			match.MatchStatus = models.END
+			return match.WinnerID
+		} else if match.LoserID == match.Device2ID {
+			match.WinnerID = match.Device1ID
+			match.MatchStatus = models.END
+			return match.WinnerID
+		}
+	} else if len(match.WinnerID) > 0 && len(match.LoserID) == 0 {
+		if match.WinnerID == match.Device1ID {
+			match.LoserID = match.Device2ID
+			match.MatchStatus = models.END
+			return match.WinnerID
+		} else if match.WinnerID == match.Device2ID {
+			match.LoserID = match.Device1ID
+			match.MatchStatus = models.END
+			return match.WinnerID
+		}
+	}
+	match.MatchStatus = models.INV
+	return ""
+}
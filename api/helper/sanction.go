@@ -0,0 +1,34 @@
+package helper
+
+import (
+	"time"
+
+	"earthshaker/api/models"
+)
+
+//BlockingSanction : the sanction that keeps a device out, ranked restrictions only count for ranked game modes, nil if none.
+func BlockingSanction(sanctions []models.Sanction, ranked bool) *models.Sanction {
+	for i := range sanctions {
+		if sanctions[i].SanctionType == models.BAN || sanctions[i].SanctionType == models.SUSPENSION {
+			return &sanctions[i]
+		}
+	}
+	if !ranked {
+		return nil
+	}
+	for i := range sanctions {
+		if sanctions[i].SanctionType == models.RANKEDBAN {
+			return &sanctions[i]
+		}
+	}
+	return nil
+}
+
+//SanctionMessage : the message shown to a sanctioned player
+func SanctionMessage(sanction *models.Sanction) string {
+	message := sanction.SanctionType + ": " + sanction.Reason
+	if !sanction.ExpiredTime.IsZero() {
+		message += " (until " + sanction.ExpiredTime.UTC().Format(time.RFC3339) + ")"
+	}
+	return message
+}
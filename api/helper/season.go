@@ -0,0 +1,10 @@
+package helper
+
+import (
+	"math"
+)
+
+//SoftResetMMR : pull the mmr toward the base by the factor, 0 resets to the base and 1 keeps the mmr.
+func SoftResetMMR(mmr int64, base int64, factor float64) int64 {
+	return base + int64(math.Round(float64(mmr-base)*factor))
+}
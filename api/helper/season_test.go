@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestSoftResetMMR(t *testing.T) {
+	tests := []struct {
+		name   string
+		mmr    int64
+		base   int64
+		factor float64
+		want   int64
+	}{
+		{"halfway down to the base", 2000, 1000, 0.5, 1500},
+		{"halfway up to the base", 600, 1000, 0.5, 800},
+		{"zero resets to the base", 2000, 1000, 0, 1000},
+		{"one keeps the mmr", 2000, 1000, 1, 2000},
+		{"at the base it stays", 1000, 1000, 0.3, 1000},
+		{"rounds half away from the base", 1005, 1000, 0.5, 1003},
+		{"rounds half away from the base below it", 995, 1000, 0.5, 997},
+		{"a zero base", 15, 0, 0.5, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SoftResetMMR(tt.mmr, tt.base, tt.factor); got != tt.want {
+				t.Errorf("SoftResetMMR(%d, %d, %v) = %d, want %d", tt.mmr, tt.base, tt.factor, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//DeviceSigningKey : the request signing key of a device, derived from the server secret and the token generation,
+//so it changes with the device secret. The first generation keeps the key of devices signing before generations.
+func DeviceSigningKey(secret string, deviceID string, generation int64) string {
+	data := "sign:" + deviceID
+	if generation > 0 {
+		data += ":" + strconv.FormatInt(generation, 10)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//CanonicalRequest : the signed string, one line for each of method, path, timestamp, nonce and the hex SHA-256 of the body.
+func CanonicalRequest(method string, path string, timestamp string, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{method, path, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+}
+
+//SignRequest : base64 HMAC-SHA256 of the canonical request with the signing key.
+func SignRequest(key string, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(canonical))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//NonceCache : remember nonces until they expire, so a signed request can only be used once.
+type NonceCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	nonces map[string]time.Time
+	purged time.Time
+}
+
+//NewNonceCache :
+func NewNonceCache(ttl time.Duration) *NonceCache {
+	return &NonceCache{
+		ttl:    ttl,
+		nonces: map[string]time.Time{},
+	}
+}
+
+//Use : mark the nonce as used, false if it was already used.
+func (c *NonceCache) Use(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.purged) > c.ttl {
+		for key, expiry := range c.nonces {
+			if now.After(expiry) {
+				delete(c.nonces, key)
+			}
+		}
+		c.purged = now
+	}
+	if expiry, exist := c.nonces[nonce]; exist && !now.After(expiry) {
+		return false
+	}
+	c.nonces[nonce] = now.Add(c.ttl)
+	return true
+}
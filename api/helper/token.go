@@ -0,0 +1,92 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+//DeviceClaims : claims of a device token
+type DeviceClaims struct {
+	Subject    string `json:"sub"`
+	Generation int64  `json:"gen,omitempty"`
+	IssuedAt   int64  `json:"iat"`
+	ExpiresAt  int64  `json:"exp"`
+}
+
+var tokenHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+//SignDeviceToken : issue a HS256 JWT bound to the device id and its token generation.
+func SignDeviceToken(secret string, deviceID string, generation int64, issuedAt time.Time, ttl time.Duration) (string, error) {
+	claims := DeviceClaims{
+		Subject:    deviceID,
+		Generation: generation,
+		IssuedAt:   issuedAt.Unix(),
+		ExpiresAt:  issuedAt.Add(ttl).Unix(),
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := tokenHeader + "." + base64.RawURLEncoding.EncodeToString(body)
+	return unsigned + "." + signToken(secret, unsigned), nil
+}
+
+//ParseDeviceToken : verify a token issued by SignDeviceToken and return its claims.
+func ParseDeviceToken(secret string, token string, now time.Time) (DeviceClaims, error) {
+	var claims DeviceClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != tokenHeader {
+		return claims, errors.New("InvalidToken")
+	}
+	expected := signToken(secret, parts[0]+"."+parts[1])
+	if !hmac.Equal([]byte(expected), []byte(parts[2])) {
+		return claims, errors.New("InvalidToken")
+	}
+	body, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, errors.New("InvalidToken")
+	}
+	if err := json.Unmarshal(body, &claims); err != nil || len(claims.Subject) == 0 {
+		return claims, errors.New("InvalidToken")
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, errors.New("ExpiredToken")
+	}
+	return claims, nil
+}
+
+func signToken(secret string, unsigned string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+//NewDeviceSecret : a random secret, only its hash is stored.
+func NewDeviceSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+//HashDeviceSecret : the stored form of a device secret or claim code.
+func HashDeviceSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+//MatchDeviceSecret : check a secret against a stored hash, an empty hash matches nothing.
+func MatchDeviceSecret(secret string, secretHash string) bool {
+	if len(secret) == 0 || len(secretHash) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(HashDeviceSecret(secret)), []byte(secretHash))
+}
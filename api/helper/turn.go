@@ -0,0 +1,17 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"strconv"
+	"time"
+)
+
+//TURNCredential : TURN REST API credential, the username is "expiry:device:match" and the password is its base64 HMAC-SHA1 with the shared secret.
+func TURNCredential(secret string, deviceID string, matchID string, expiry time.Time) (string, string) {
+	username := strconv.FormatInt(expiry.Unix(), 10) + ":" + deviceID + ":" + matchID
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(username))
+	return username, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
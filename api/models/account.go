@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Account : the devices of a player, the player data is kept under the player id,
+//which is the id of the first device of the account.
+type Account struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	PlayerID    string             `bson:"player_id,omitempty" json:"player_id,omitempty"`
+	DeviceIDs   []string           `bson:"device_ids,omitempty" json:"device_ids,omitempty"`
+	UpdatedTime time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
+
+//LinkCode : a one-time code to link another device to the account of a player
+type LinkCode struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	Code        string             `bson:"code,omitempty" json:"code,omitempty"`
+	PlayerID    string             `bson:"player_id,omitempty" json:"player_id,omitempty"`
+	CreatedBy   string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	UsedBy      string             `bson:"used_by,omitempty" json:"used_by,omitempty"`
+	ExpiresTime time.Time          `bson:"expires_time,omitempty" json:"expires_time,omitempty"`
+	UsedTime    time.Time          `bson:"used_time,omitempty" json:"used_time,omitempty"`
+	CreatedTime time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
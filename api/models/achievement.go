@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//METRICWINS : metrics achievements are defined on
+const (
+	METRICWINS          = "Wins"
+	METRICBESTSTREAK    = "BestStreak"
+	METRICNATIONSPLAYED = "NationsPlayed"
+	METRICBEATRANK      = "BeatRank"
+)
+
+//Achievement : an achievement granted to a player, once.
+type Achievement struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	DeviceID      string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	AchievementID string             `bson:"achievement_id,omitempty" json:"achievement_id,omitempty"`
+	MatchID       string             `bson:"match_id,omitempty" json:"match_id,omitempty"`
+	GrantedTime   time.Time          `bson:"granted_time,omitempty" json:"granted_time,omitempty"`
+}
+
+//Progress : the metrics of a player after a match
+type Progress struct {
+	Wins          int64
+	BestStreak    int64
+	NationsPlayed int64
+	BeatRank      int64
+}
@@ -0,0 +1,8 @@
+package models
+
+//ROLEVIEWER : admin roles
+const (
+	ROLEVIEWER    = "Viewer"
+	ROLEOPERATOR  = "Operator"
+	ROLEMODERATOR = "Moderator"
+)
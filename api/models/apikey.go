@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//APIKey : an app key, valid from NotBefore until NotAfter unless it is revoked, zero times mean no bound.
+type APIKey struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	KeyID       string             `bson:"key_id,omitempty" json:"key_id,omitempty"`
+	Key         string             `bson:"key,omitempty" json:"-"`
+	NotBefore   time.Time          `bson:"not_before,omitempty" json:"not_before,omitempty"`
+	NotAfter    time.Time          `bson:"not_after,omitempty" json:"not_after,omitempty"`
+	Revoked     bool               `bson:"revoked,omitempty" json:"revoked,omitempty"`
+	UpdatedTime time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//ACTORDEVICE : audit actor types
+const (
+	ACTORDEVICE = "Device"
+	ACTORADMIN  = "Admin"
+	ACTORCRON   = "Cron"
+)
+
+//AUDITUPSERTSTATUS : audited actions
+const (
+	AUDITUPSERTSTATUS = "UpsertStatus"
+	AUDITREPORTRESULT = "ReportResult"
+	AUDITCREATEMATCH  = "CreateMatch"
+	AUDITRESOLVEMATCH = "ResolveMatch"
+	AUDITUPDATEMMR    = "UpdateMMR"
+	AUDITMATCHSTATUS  = "ForceMatchStatus"
+	AUDITADJUSTMMR    = "AdjustMMR"
+	AUDITSANCTION     = "CreateSanction"
+	AUDITLIFTSANCTION = "LiftSanction"
+	AUDITHANDLEREPORT = "HandleReport"
+	AUDITDEVICECLAIM  = "CreateDeviceClaim"
+	AUDITLINKDEVICE   = "LinkDevice"
+	AUDITCREATESEASON = "CreateSeason"
+)
+
+//Audit : an entry of the append-only audit log, Before and After only hold the changed fields.
+type Audit struct {
+	ID          primitive.ObjectID     `bson:"_id,omitempty" json:"id,omitempty"`
+	ActorType   string                 `bson:"actor_type,omitempty" json:"actor_type,omitempty"`
+	ActorID     string                 `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	Action      string                 `bson:"action,omitempty" json:"action,omitempty"`
+	TargetID    string                 `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Before      map[string]interface{} `bson:"before,omitempty" json:"before,omitempty"`
+	After       map[string]interface{} `bson:"after,omitempty" json:"after,omitempty"`
+	RequestID   string                 `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	CreatedTime time.Time              `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
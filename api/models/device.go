@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Device : a registered device, a device without a secret is waiting to be claimed.
+//Only tokens of the current token generation are accepted, it moves on when the secret is replaced.
+type Device struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	DeviceID         string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	SecretHash       string             `bson:"secret_hash,omitempty" json:"-"`
+	ClaimHash        string             `bson:"claim_hash,omitempty" json:"-"`
+	ClaimExpiresTime time.Time          `bson:"claim_expires_time,omitempty" json:"-"`
+	TokenGeneration  int64              `bson:"token_generation,omitempty" json:"-"`
+	UpdatedTime      time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime      time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
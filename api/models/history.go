@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//HistoryFilter : filters of the match history of a player, empty values match all
+type HistoryFilter struct {
+	From        time.Time
+	To          time.Time
+	OpponentID  string
+	Result      string
+	GameMode    string
+	MatchStatus string
+	SeasonID    string
+}
+
+//MatchHistory : a match of a player with the status of the opponent
+type MatchHistory struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	MatchStatus string             `bson:"match_status,omitempty" json:"match_status,omitempty"`
+	GameMode    string             `bson:"game_mode,omitempty" json:"game_mode,omitempty"`
+	SeasonID    string             `bson:"season_id,omitempty" json:"season_id,omitempty"`
+	WinnerID    string             `bson:"winner_id,omitempty" json:"winner_id,omitempty"`
+	LoserID     string             `bson:"loser_id,omitempty" json:"loser_id,omitempty"`
+	EndReason   string             `bson:"end_reason,omitempty" json:"end_reason,omitempty"`
+	OpponentID  string             `bson:"opponent_id,omitempty" json:"opponent_id,omitempty"`
+	Opponent    Status             `bson:"opponent,omitempty" json:"opponent,omitempty"`
+	StartedTime time.Time          `bson:"started_time,omitempty" json:"started_time,omitempty"`
+	CreatedTime time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+	UpdatedTime time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+}
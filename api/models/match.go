@@ -16,27 +16,55 @@ const (
 	INV   = "Invalid"
 )
 
+//FORFEIT : match end reasons
+const (
+	FORFEIT = "Forfeit"
+	ABANDON = "Abandoned"
+	TIMEOUT = "Timeout"
+)
+
+//MaxWebRTCMessages : max number of signaling messages kept in a match
+const MaxWebRTCMessages = 200
+
 //Match contains match info.
 type Match struct {
-	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Device1ID        string             `bson:"device1_id,omitempty" json:"device1_id,omitempty"`
-	Device2ID        string             `bson:"device2_id,omitempty" json:"device2_id,omitempty"`
-	FirstConnectID   string             `bson:"first_connect_id",omitempty" json:"first_connect_id,omitempty"`
-	MatchStatus      string             `bson:"match_status,omitempty" json:"match_status,omitempty"`
-	WinnerID         string             `bson:"winner_id,omitempty" json:"winner_id,omitempty"`
-	LoserID          string             `bson:"loser_id,omitempty" json:"loser_id,omitempty"`
-	FirstTurnID      string             `bson:"first_turn_id,omitempty" json:"first_turn_id,omitempty"`
-	WebRTCOffer      string             `bson:"webrtc_offer,omitempty" json:"webrtc_offer,omitempty"`
-	WebRTCCandidates string             `bson:"webrtc_candidates,omitempty" json:"webrtc_candidates,omitempty"`
-	WebRTCAnswer     string             `bson:"webrtc_answer,omitempty" json:"webrtc_answer,omitempty"`
-	Moves            []Move             `bson:"moves,omitempty" json:"moves,omitempty"`
-	CreatedTime      time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
-	UpdatedTime      time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Device1ID       string             `bson:"device1_id,omitempty" json:"device1_id,omitempty"`
+	Device2ID       string             `bson:"device2_id,omitempty" json:"device2_id,omitempty"`
+	Device1Rank     int64              `bson:"device1_rank,omitempty" json:"-"`
+	Device2Rank     int64              `bson:"device2_rank,omitempty" json:"-"`
+	FirstConnectID  string             `bson:"first_connect_id,omitempty" json:"first_connect_id,omitempty"`
+	MatchStatus     string             `bson:"match_status,omitempty" json:"match_status,omitempty"`
+	GameMode        string             `bson:"game_mode,omitempty" json:"game_mode,omitempty"`
+	SeasonID        string             `bson:"season_id,omitempty" json:"season_id,omitempty"`
+	WinnerID        string             `bson:"winner_id,omitempty" json:"winner_id,omitempty"`
+	LoserID         string             `bson:"loser_id,omitempty" json:"loser_id,omitempty"`
+	EndReason       string             `bson:"end_reason,omitempty" json:"end_reason,omitempty"`
+	FirstTurnID     string             `bson:"first_turn_id,omitempty" json:"first_turn_id,omitempty"`
+	WebRTCMessages  []WebRTCMessage    `bson:"webrtc_messages,omitempty" json:"webrtc_messages,omitempty"`
+	Moves           []Move             `bson:"moves,omitempty" json:"moves,omitempty"`
+	Device1SeenTime time.Time          `bson:"device1_seen_time,omitempty" json:"device1_seen_time,omitempty"`
+	Device2SeenTime time.Time          `bson:"device2_seen_time,omitempty" json:"device2_seen_time,omitempty"`
+	StartedTime     time.Time          `bson:"started_time,omitempty" json:"started_time,omitempty"`
+	EndedTime       time.Time          `bson:"ended_time,omitempty" json:"ended_time,omitempty"`
+	AchievedTime    time.Time          `bson:"achieved_time,omitempty" json:"-"`
+	CreatedTime     time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+	UpdatedTime     time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
 }
 
 //Move :
 type Move struct {
-	DeviceID string `bson:"device_id,omitempty" json:"device_id,omitempty"`
-	Sequence int    `bson:"sequence,omitempty" json:"sequence,omitempty"`
-	Step     string `bson:"step,omitempty" json:"step,omitempty"`
+	DeviceID    string    `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	Sequence    int       `bson:"sequence,omitempty" json:"sequence,omitempty"`
+	Step        string    `bson:"step,omitempty" json:"step,omitempty"`
+	CreatedTime time.Time `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
+
+//WebRTCMessage : a signaling message sent by a player to its peer, only appended.
+type WebRTCMessage struct {
+	FromID      string    `bson:"from_id,omitempty" json:"from_id,omitempty"`
+	ToID        string    `bson:"to_id,omitempty" json:"to_id,omitempty"`
+	Type        string    `bson:"type,omitempty" json:"type,omitempty"`
+	Message     string    `bson:"message,omitempty" json:"message,omitempty"`
+	CreatedTime time.Time `bson:"created_time,omitempty" json:"created_time,omitempty"`
 }
@@ -0,0 +1,12 @@
+package models
+
+//NationStats : aggregates of the players of a nation
+type NationStats struct {
+	Nation        string  `bson:"_id" json:"nation"`
+	Players       int64   `bson:"players" json:"players"`
+	ActivePlayers int64   `bson:"active_players" json:"active_players"`
+	AverageMMR    float64 `bson:"average_mmr" json:"average_mmr"`
+	Wins          int64   `bson:"wins" json:"wins"`
+	Games         int64   `bson:"games" json:"games"`
+	WinRate       float64 `bson:"win_rate" json:"win_rate"`
+}
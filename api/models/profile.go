@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Profile : the customization of a player, the presence stays in the status.
+type Profile struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	DeviceID     string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	AvatarID     string             `bson:"avatar_id,omitempty" json:"avatar_id,omitempty"`
+	Title        string             `bson:"title,omitempty" json:"title,omitempty"`
+	PlayerNation string             `bson:"player_nation,omitempty" json:"player_nation,omitempty"`
+	Bio          string             `bson:"bio,omitempty" json:"bio,omitempty"`
+	UpdatedTime  time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime  time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+//RateBucket : a token bucket of a rate limit key.
+type RateBucket struct {
+	Key         string    `bson:"_id" json:"key"`
+	Tokens      float64   `bson:"tokens" json:"tokens"`
+	UpdatedTime time.Time `bson:"updated_time" json:"updated_time"`
+	ExpiredTime time.Time `bson:"expired_time" json:"expired_time"`
+}
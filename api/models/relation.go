@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//PENDINGFRIEND : relation statuses
+const (
+	PENDINGFRIEND = "Pending"
+	FRIEND        = "Friend"
+	BLOCKED       = "Blocked"
+)
+
+//Relation : a relation from a player to another one, a friendship is kept in both directions,
+//a request and a block only in the direction of the player who made it.
+type Relation struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	DeviceID       string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	TargetID       string             `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	RelationStatus string             `bson:"relation_status,omitempty" json:"relation_status,omitempty"`
+	UpdatedTime    time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime    time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//CHEATING : report categories
+const (
+	CHEATING    = "Cheating"
+	ABUSIVENAME = "AbusiveName"
+	HARASSMENT  = "Harassment"
+	OTHERREPORT = "Other"
+)
+
+//OPENREPORT : report statuses
+const (
+	OPENREPORT      = "Open"
+	DISMISSEDREPORT = "Dismissed"
+	ESCALATEDREPORT = "Escalated"
+)
+
+//Report : a report of a player, waiting in the moderation queue until it is dismissed or escalated.
+type Report struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ReporterID   string             `bson:"reporter_id,omitempty" json:"reporter_id,omitempty"`
+	ReportedID   string             `bson:"reported_id,omitempty" json:"reported_id,omitempty"`
+	ReportedName string             `bson:"reported_name,omitempty" json:"reported_name,omitempty"`
+	MatchID      string             `bson:"match_id,omitempty" json:"match_id,omitempty"`
+	Category     string             `bson:"category,omitempty" json:"category,omitempty"`
+	Message      string             `bson:"message,omitempty" json:"message,omitempty"`
+	ReportStatus string             `bson:"report_status,omitempty" json:"report_status,omitempty"`
+	HandledBy    string             `bson:"handled_by,omitempty" json:"handled_by,omitempty"`
+	Note         string             `bson:"note,omitempty" json:"note,omitempty"`
+	SanctionID   string             `bson:"sanction_id,omitempty" json:"sanction_id,omitempty"`
+	HandledTime  time.Time          `bson:"handled_time,omitempty" json:"handled_time,omitempty"`
+	UpdatedTime  time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime  time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
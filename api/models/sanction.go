@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//BAN : sanction types
+const (
+	BAN        = "Ban"
+	SUSPENSION = "Suspension"
+	RANKEDBAN  = "RankedRestriction"
+)
+
+//Sanction : a sanction of a device, active until it expires or is lifted, a zero expiry never expires.
+type Sanction struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	DeviceID     string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	SanctionType string             `bson:"sanction_type,omitempty" json:"sanction_type,omitempty"`
+	Reason       string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	CreatedBy    string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	ExpiredTime  time.Time          `bson:"expired_time,omitempty" json:"expired_time,omitempty"`
+	LiftedBy     string             `bson:"lifted_by,omitempty" json:"lifted_by,omitempty"`
+	LiftedTime   time.Time          `bson:"lifted_time,omitempty" json:"lifted_time,omitempty"`
+	UpdatedTime  time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime  time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Season : a competitive season in [StartTime, EndTime), it is rolled over once it ends.
+type Season struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SeasonID       string             `bson:"season_id,omitempty" json:"season_id,omitempty"`
+	Name           string             `bson:"name,omitempty" json:"name,omitempty"`
+	StartTime      time.Time          `bson:"start_time,omitempty" json:"start_time,omitempty"`
+	EndTime        time.Time          `bson:"end_time,omitempty" json:"end_time,omitempty"`
+	ArchivedTime   time.Time          `bson:"archived_time,omitempty" json:"archived_time,omitempty"`
+	RolledOverTime time.Time          `bson:"rolled_over_time,omitempty" json:"rolled_over_time,omitempty"`
+	UpdatedTime    time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
+	CreatedTime    time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
+
+//SeasonRecord : the final rank and mmr of a player in a season, and the mmr the next season starts from.
+type SeasonRecord struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	SeasonID        string             `bson:"season_id,omitempty" json:"season_id,omitempty"`
+	DeviceID        string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	PlayerName      string             `bson:"player_name,omitempty" json:"player_name,omitempty"`
+	PlayerNation    string             `bson:"player_nation,omitempty" json:"player_nation,omitempty"`
+	FinalRank       int64              `bson:"final_rank" json:"final_rank"`
+	FinalNationRank int64              `bson:"final_nation_rank,omitempty" json:"final_nation_rank,omitempty"`
+	FinalMMR        int64              `bson:"final_mmr" json:"final_mmr"`
+	ResetMMR        int64              `bson:"reset_mmr" json:"reset_mmr"`
+	ResetTime       time.Time          `bson:"reset_time,omitempty" json:"-"`
+	CreatedTime     time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
+}
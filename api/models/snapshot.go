@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+//RankSnapshot : the rank of a player when the snapshot was taken.
+//Percentile is the share of players ranked at or below the player, the top one has 100.
+type RankSnapshot struct {
+	DeviceID     string    `bson:"_id" json:"device_id"`
+	Rank         int64     `bson:"rank" json:"rank"`
+	NationRank   int64     `bson:"nation_rank,omitempty" json:"nation_rank,omitempty"`
+	Percentile   float64   `bson:"percentile" json:"percentile"`
+	PlayerMMR    int64     `bson:"player_mmr" json:"player_mmr"`
+	TotalPlayers int64     `bson:"total_players" json:"total_players"`
+	SnapshotTime time.Time `bson:"snapshot_time" json:"snapshot_time"`
+}
+
+//FrozenRank : the mmr of a player copied when a snapshot starts, the snapshot ranks these copies.
+type FrozenRank struct {
+	DeviceID     string    `bson:"_id" json:"device_id"`
+	PlayerNation string    `bson:"player_nation,omitempty" json:"player_nation,omitempty"`
+	PlayerMMR    int64     `bson:"player_mmr" json:"player_mmr"`
+	SnapshotTime time.Time `bson:"snapshot_time" json:"snapshot_time"`
+}
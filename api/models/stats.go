@@ -0,0 +1,32 @@
+package models
+
+//WIN : results of a player in a match
+const (
+	WIN  = "Win"
+	LOSS = "Loss"
+	DRAW = "Draw"
+)
+
+//PlayerStats : the record of a player over the ended matches
+type PlayerStats struct {
+	Games               int64           `bson:"games" json:"games"`
+	Wins                int64           `bson:"wins" json:"wins"`
+	Losses              int64           `bson:"losses" json:"losses"`
+	Draws               int64           `bson:"draws" json:"draws"`
+	WinRate             float64         `bson:"-" json:"win_rate"`
+	CurrentStreak       int64           `bson:"-" json:"current_streak"`
+	BestStreak          int64           `bson:"-" json:"best_streak"`
+	AverageMatchSeconds float64         `bson:"average_match_seconds" json:"average_match_seconds"`
+	Opponents           []OpponentStats `bson:"-" json:"opponents"`
+	HeadToHead          *OpponentStats  `bson:"-" json:"head_to_head,omitempty"`
+}
+
+//OpponentStats : the record of a player against an opponent
+type OpponentStats struct {
+	DeviceID   string `bson:"_id" json:"device_id"`
+	PlayerName string `bson:"-" json:"player_name,omitempty"`
+	Games      int64  `bson:"games" json:"games"`
+	Wins       int64  `bson:"wins" json:"wins"`
+	Losses     int64  `bson:"losses" json:"losses"`
+	Draws      int64  `bson:"draws" json:"draws"`
+}
@@ -19,9 +19,11 @@ type Status struct {
 	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	DeviceID     string             `bson:"device_id,omitempty" json:"device_id,omitempty"`
 	PlayerName   string             `bson:"player_name,omitempty" json:"player_name,omitempty"`
+	NameKey      string             `bson:"name_key,omitempty" json:"-"`
 	PlayerStatus string             `bson:"player_status,omitempty" json:"player_status,omitempty"`
 	PlayerNation string             `bson:"player_nation,omitempty" json:"player_nation,omitempty"`
-	PlayerMMR    int64              `bson:"player_mmr,omitempty" json:"player_mmr,omitempty"`
+	PlayerMMR    int64              `bson:"player_mmr" json:"player_mmr"`
+	GameMode     string             `bson:"game_mode,omitempty" json:"game_mode,omitempty"`
 	UpdatedTime  time.Time          `bson:"updated_time,omitempty" json:"updated_time,omitempty"`
 	CreatedTime  time.Time          `bson:"created_time,omitempty" json:"created_time,omitempty"`
 }
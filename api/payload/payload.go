@@ -12,12 +12,49 @@ type ResResult struct {
 	Result interface{} `json:"result,omitempty"`
 }
 
+//ReqRegisterDevice :
+type ReqRegisterDevice struct {
+	DeviceID string `json:"device_id"`
+}
+
+//ReqAuthenticateDevice :
+type ReqAuthenticateDevice struct {
+	DeviceID     string `json:"device_id"`
+	DeviceSecret string `json:"device_secret"`
+}
+
+//ReqClaimDevice :
+type ReqClaimDevice struct {
+	DeviceID  string `json:"device_id"`
+	ClaimCode string `json:"claim_code"`
+}
+
+//ResDeviceToken : the device secret is only given when it is issued
+type ResDeviceToken struct {
+	Token        string `json:"token"`
+	ExpiresAt    int64  `json:"expires_at"`
+	SigningKey   string `json:"signing_key"`
+	DeviceSecret string `json:"device_secret,omitempty"`
+}
+
+//ReqAdminDeviceClaim :
+type ReqAdminDeviceClaim struct {
+	DeviceID string `json:"device_id"`
+}
+
+//ResAdminDeviceClaim :
+type ResAdminDeviceClaim struct {
+	ClaimCode string `json:"claim_code"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
 //ReqUpsertStatus :
 type ReqUpsertStatus struct {
 	DeviceID     string `json:"device_id"`
 	PlayerName   string `json:"player_name"`
 	PlayerNation string `json:"player_nation"`
 	PlayerStatus string `json:"player_status"`
+	GameMode     string `json:"game_mode"`
 }
 
 //ReqFindMatch :
@@ -38,11 +75,64 @@ type ReqReadyMatch struct {
 
 //ResReadyMatch :
 type ResReadyMatch struct {
-	MatchID     string `json:"match_id"`
-	FirstTurn   bool   `json:"first_turn"`
-	EnemyID     string `json:"enemy_id,omitempty"`
-	EnemyName   string `json:"enemy_name,omitempty"`
-	EnemyNation string `json:"enemy_nation,omitempty"`
+	MatchID      string `json:"match_id"`
+	FirstTurn    bool   `json:"first_turn"`
+	EnemyID      string `json:"enemy_id,omitempty"`
+	EnemyName    string `json:"enemy_name,omitempty"`
+	EnemyNation  string `json:"enemy_nation,omitempty"`
+	EnemyAvatar  string `json:"enemy_avatar_id,omitempty"`
+	EnemyTitle   string `json:"enemy_title,omitempty"`
+	GameMode     string `json:"game_mode,omitempty"`
+	TurnSeconds  int64  `json:"turn_seconds,omitempty"`
+	MatchSeconds int64  `json:"match_seconds,omitempty"`
+}
+
+//ReqResumeMatch :
+type ReqResumeMatch struct {
+	DeviceID string `json:"device_id"`
+	Sequence int    `json:"sequence"`
+}
+
+//ResResumeMatch :
+type ResResumeMatch struct {
+	MatchID      string    `json:"match_id,omitempty"`
+	FirstTurn    bool      `json:"first_turn"`
+	EnemyID      string    `json:"enemy_id,omitempty"`
+	EnemyName    string    `json:"enemy_name,omitempty"`
+	EnemyNation  string    `json:"enemy_nation,omitempty"`
+	EnemyAvatar  string    `json:"enemy_avatar_id,omitempty"`
+	EnemyTitle   string    `json:"enemy_title,omitempty"`
+	GraceSeconds int64     `json:"grace_seconds,omitempty"`
+	Moves        []ResMove `json:"moves,omitempty"`
+}
+
+//ResMove :
+type ResMove struct {
+	DeviceID string `json:"device_id,omitempty"`
+	Sequence int    `json:"sequence,omitempty"`
+	Step     string `json:"step,omitempty"`
+	MoveTime string `json:"move_time,omitempty"`
+}
+
+//ReqReplay :
+type ReqReplay struct {
+	DeviceID string `json:"device_id"`
+	MatchID  string `json:"match_id"`
+}
+
+//ResReplay :
+type ResReplay struct {
+	MatchID     string    `json:"match_id,omitempty"`
+	MatchStatus string    `json:"match_status,omitempty"`
+	MatchDate   string    `json:"match_date,omitempty"`
+	EndDate     string    `json:"end_date,omitempty"`
+	Device1ID   string    `json:"device1_id,omitempty"`
+	Device2ID   string    `json:"device2_id,omitempty"`
+	FirstTurnID string    `json:"first_turn_id,omitempty"`
+	WinnerID    string    `json:"winner_id,omitempty"`
+	LoserID     string    `json:"loser_id,omitempty"`
+	EndReason   string    `json:"end_reason,omitempty"`
+	Moves       []ResMove `json:"moves"`
 }
 
 //ReqSendRTCMessage :
@@ -58,13 +148,41 @@ type ReqReceiveRTCMessage struct {
 	DeviceID   string `json:"device_id"`
 	MatchID    string `json:"match_id"`
 	WebRTCType string `json:"webrtc_type"`
+	Since      int    `json:"since"`
 }
 
 //ResReceiveRTCMessage :
 type ResReceiveRTCMessage struct {
+	MatchID          string   `json:"match_id,omitempty"`
+	WebRTCType       string   `json:"webrtc_type,omitempty"`
+	WebRTCMessage    string   `json:"webrtc_message,omitempty"`
+	WebRTCCandidates []string `json:"webrtc_candidates,omitempty"`
+	Next             int      `json:"next,omitempty"`
+}
+
+//ResLiveMatches :
+type ResLiveMatches struct {
+	DelaySeconds int64          `json:"delay_seconds"`
+	Matches      []ResLiveMatch `json:"matches"`
+}
+
+//ResLiveMatch :
+type ResLiveMatch struct {
 	MatchID       string `json:"match_id,omitempty"`
-	WebRTCType    string `json:"webrtc_type,omitempty"`
-	WebRTCMessage string `json:"webrtc_message,omitempty"`
+	GameMode      string `json:"game_mode,omitempty"`
+	StartDate     string `json:"start_date,omitempty"`
+	Player1ID     string `json:"player1_id,omitempty"`
+	Player1Name   string `json:"player1_name,omitempty"`
+	Player1Nation string `json:"player1_nation,omitempty"`
+	Player2ID     string `json:"player2_id,omitempty"`
+	Player2Name   string `json:"player2_name,omitempty"`
+	Player2Nation string `json:"player2_nation,omitempty"`
+}
+
+//ReqSpectateMove :
+type ReqSpectateMove struct {
+	MatchID  string `json:"match_id"`
+	Sequence int    `json:"sequence"`
 }
 
 //ReqMatchResult :
@@ -78,6 +196,7 @@ type ReqMatchResult struct {
 type ReqGetRank struct {
 	DeviceID   string `json:"device_id"`
 	MatchLimit int64  `json:"match_limit"`
+	SeasonID   string `json:"season_id"`
 }
 
 //ResGetRank :
@@ -85,7 +204,13 @@ type ResGetRank struct {
 	TopRankPlayerID     string            `json:"top_rank_id,omitempty"`
 	TopRankPlayerName   string            `json:"top_rank_name,omitempty"`
 	TopRankPlayerNation string            `json:"top_rank_nation,omitempty"`
+	TopRankPlayerAvatar string            `json:"top_rank_avatar_id,omitempty"`
+	TopRankPlayerTitle  string            `json:"top_rank_title,omitempty"`
 	YourCurrentRank     int64             `json:"your_rank,omitempty"`
+	YourNationRank      int64             `json:"your_nation_rank,omitempty"`
+	YourPercentile      float64           `json:"your_percentile,omitempty"`
+	RankTime            string            `json:"rank_time,omitempty"`
+	RankFromSnapshot    bool              `json:"rank_from_snapshot"`
 	LastestMatches      []ResGetRankMatch `json:"lastest_matches,omitempty"`
 }
 
@@ -96,9 +221,30 @@ type ResGetRankMatch struct {
 	EnemyID     string `json:"enemy_id,omitempty"`
 	EnemyName   string `json:"enemy_name,omitempty"`
 	EnemyNation string `json:"enemy_nation,omitempty"`
+	EnemyAvatar string `json:"enemy_avatar_id,omitempty"`
+	EnemyTitle  string `json:"enemy_title,omitempty"`
 	Win         bool   `json:"win"`
 }
 
+//ReqICEServers :
+type ReqICEServers struct {
+	DeviceID string `json:"device_id"`
+	MatchID  string `json:"match_id"`
+}
+
+//ResICEServers :
+type ResICEServers struct {
+	TTLSeconds int64          `json:"ttl_seconds,omitempty"`
+	ICEServers []ResICEServer `json:"ice_servers"`
+}
+
+//ResICEServer :
+type ResICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
+
 //ReqSendMove :
 type ReqSendMove struct {
 	MatchID  string `json:"match_id"`
@@ -120,3 +266,316 @@ type ResReceiveMove struct {
 	Sequence int    `json:"sequence,omitempty"`
 	Step     string `json:"step,omitempty"`
 }
+
+//ReqAdminSearchPlayers :
+type ReqAdminSearchPlayers struct {
+	DeviceID     string `json:"device_id"`
+	PlayerName   string `json:"player_name"`
+	PlayerStatus string `json:"player_status"`
+	Skip         int64  `json:"skip"`
+	Limit        int64  `json:"limit"`
+}
+
+//ReqAdminSearchMatches :
+type ReqAdminSearchMatches struct {
+	DeviceID    string `json:"device_id"`
+	MatchStatus string `json:"match_status"`
+	SeasonID    string `json:"season_id"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Skip        int64  `json:"skip"`
+	Limit       int64  `json:"limit"`
+}
+
+//ReqAdminMatchStatus :
+type ReqAdminMatchStatus struct {
+	MatchID     string `json:"match_id"`
+	MatchStatus string `json:"match_status"`
+}
+
+//ReqAdminAdjustMMR :
+type ReqAdminAdjustMMR struct {
+	DeviceID string `json:"device_id"`
+	Delta    int64  `json:"delta"`
+}
+
+//ReqAdminResolveMatch :
+type ReqAdminResolveMatch struct {
+	MatchID  string `json:"match_id"`
+	WinnerID string `json:"winner_id"`
+}
+
+//ReqAdminCreateSanction :
+type ReqAdminCreateSanction struct {
+	DeviceID      string `json:"device_id"`
+	SanctionType  string `json:"sanction_type"`
+	Reason        string `json:"reason"`
+	DurationHours int64  `json:"duration_hours"`
+}
+
+//ReqAdminLiftSanction :
+type ReqAdminLiftSanction struct {
+	SanctionID string `json:"sanction_id"`
+}
+
+//ReqAdminListSanctions :
+type ReqAdminListSanctions struct {
+	DeviceID   string `json:"device_id"`
+	ActiveOnly bool   `json:"active_only"`
+}
+
+//ReqAdminSearchAudit :
+type ReqAdminSearchAudit struct {
+	ActorID   string `json:"actor_id"`
+	Action    string `json:"action"`
+	TargetID  string `json:"target_id"`
+	RequestID string `json:"request_id"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Skip      int64  `json:"skip"`
+	Limit     int64  `json:"limit"`
+}
+
+//ReqReportPlayer :
+type ReqReportPlayer struct {
+	ReportedID string `json:"reported_id"`
+	MatchID    string `json:"match_id"`
+	Category   string `json:"category"`
+	Message    string `json:"message"`
+}
+
+//ReqGetProfile :
+type ReqGetProfile struct {
+	DeviceID string `json:"device_id"`
+}
+
+//ReqUpsertProfile : fields left out are kept, empty fields are cleared
+type ReqUpsertProfile struct {
+	AvatarID     *string `json:"avatar_id"`
+	Title        *string `json:"title"`
+	PlayerNation *string `json:"player_nation"`
+	Bio          *string `json:"bio"`
+}
+
+//ResProfile : the name comes from the status, so does the nation when the profile has none
+type ResProfile struct {
+	DeviceID     string `json:"device_id"`
+	PlayerName   string `json:"player_name,omitempty"`
+	PlayerNation string `json:"player_nation,omitempty"`
+	AvatarID     string `json:"avatar_id,omitempty"`
+	Title        string `json:"title,omitempty"`
+	TitleName    string `json:"title_name,omitempty"`
+	Bio          string `json:"bio,omitempty"`
+	UpdatedTime  string `json:"updated_time,omitempty"`
+}
+
+//ResAccount :
+type ResAccount struct {
+	PlayerID  string   `json:"player_id"`
+	DeviceIDs []string `json:"device_ids"`
+}
+
+//ResLinkCode :
+type ResLinkCode struct {
+	Code      string `json:"code"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+//ReqLinkDevice :
+type ReqLinkDevice struct {
+	Code        string `json:"code"`
+	LeavePlayer bool   `json:"leave_player"`
+}
+
+//ReqRelation :
+type ReqRelation struct {
+	TargetID string `json:"target_id"`
+}
+
+//ResRelations : the presence is only given for friends
+type ResRelations struct {
+	Friends  []ResRelation `json:"friends"`
+	Requests []ResRelation `json:"requests"`
+	Sent     []ResRelation `json:"sent"`
+	Blocked  []ResRelation `json:"blocked"`
+}
+
+//ResRelation :
+type ResRelation struct {
+	DeviceID     string `json:"device_id"`
+	PlayerName   string `json:"player_name,omitempty"`
+	PlayerNation string `json:"player_nation,omitempty"`
+	PlayerStatus string `json:"player_status,omitempty"`
+	LastSeen     string `json:"last_seen,omitempty"`
+	Since        string `json:"since"`
+}
+
+//ReqFriendFeed :
+type ReqFriendFeed struct {
+	Cursor string `json:"cursor"`
+	Limit  int64  `json:"limit"`
+}
+
+//ResFriendFeed :
+type ResFriendFeed struct {
+	Matches []ResFeedMatch `json:"matches"`
+	Next    string         `json:"next,omitempty"`
+}
+
+//ResFeedMatch : an ended match of a friend, the result is the one of the friend
+type ResFeedMatch struct {
+	MatchID      string `json:"match_id"`
+	MatchDate    string `json:"match_date"`
+	GameMode     string `json:"game_mode,omitempty"`
+	SeasonID     string `json:"season_id,omitempty"`
+	Result       string `json:"result"`
+	EndReason    string `json:"end_reason,omitempty"`
+	FriendID     string `json:"friend_id"`
+	FriendName   string `json:"friend_name,omitempty"`
+	OpponentID   string `json:"opponent_id"`
+	OpponentName string `json:"opponent_name,omitempty"`
+}
+
+//ReqAdminSearchReports :
+type ReqAdminSearchReports struct {
+	ReportedID   string `json:"reported_id"`
+	Category     string `json:"category"`
+	ReportStatus string `json:"report_status"`
+	Skip         int64  `json:"skip"`
+	Limit        int64  `json:"limit"`
+}
+
+//ReqAdminReport :
+type ReqAdminReport struct {
+	ReportID string `json:"report_id"`
+}
+
+//ResAdminReport :
+type ResAdminReport struct {
+	Report      interface{} `json:"report"`
+	Match       interface{} `json:"match,omitempty"`
+	Moves       []ResMove   `json:"moves"`
+	OpenReports int64       `json:"open_reports"`
+	Sanctions   interface{} `json:"sanctions"`
+}
+
+//ReqAdminDismissReport :
+type ReqAdminDismissReport struct {
+	ReportID string `json:"report_id"`
+	Note     string `json:"note"`
+}
+
+//ReqAdminEscalateReport :
+type ReqAdminEscalateReport struct {
+	ReportID      string `json:"report_id"`
+	SanctionType  string `json:"sanction_type"`
+	DurationHours int64  `json:"duration_hours"`
+	Note          string `json:"note"`
+}
+
+//ResFieldError : a rejected field of a request
+type ResFieldError struct {
+	Result string `json:"result"`
+	Field  string `json:"field"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+//ReqLeaderboard :
+type ReqLeaderboard struct {
+	SeasonID string `json:"season_id"`
+	Nation   string `json:"nation"`
+	Cursor   string `json:"cursor"`
+	Limit    int64  `json:"limit"`
+}
+
+//ReqLeaderboardAround :
+type ReqLeaderboardAround struct {
+	InNation bool  `json:"in_nation"`
+	Size     int64 `json:"size"`
+}
+
+//ResLeaderboard :
+type ResLeaderboard struct {
+	Players []ResRankedPlayer `json:"players"`
+	Next    string            `json:"next,omitempty"`
+}
+
+//ResRankedPlayer :
+type ResRankedPlayer struct {
+	Rank         int64  `json:"rank"`
+	DeviceID     string `json:"device_id"`
+	PlayerName   string `json:"player_name,omitempty"`
+	PlayerNation string `json:"player_nation,omitempty"`
+	PlayerMMR    int64  `json:"player_mmr"`
+}
+
+//ResNationStats :
+type ResNationStats struct {
+	Nations     interface{} `json:"nations"`
+	UpdatedTime string      `json:"updated_time"`
+}
+
+//ReqAdminCreateSeason :
+type ReqAdminCreateSeason struct {
+	SeasonID  string `json:"season_id"`
+	Name      string `json:"name"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+//ReqPlayerStats :
+type ReqPlayerStats struct {
+	DeviceID   string `json:"device_id"`
+	OpponentID string `json:"opponent_id"`
+	SeasonID   string `json:"season_id"`
+}
+
+//ReqMatchHistory :
+type ReqMatchHistory struct {
+	Cursor      string `json:"cursor"`
+	Limit       int64  `json:"limit"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	OpponentID  string `json:"opponent_id"`
+	Result      string `json:"result"`
+	GameMode    string `json:"game_mode"`
+	MatchStatus string `json:"match_status"`
+	SeasonID    string `json:"season_id"`
+}
+
+//ResMatchHistory :
+type ResMatchHistory struct {
+	Matches []ResHistoryMatch `json:"matches"`
+	Next    string            `json:"next,omitempty"`
+}
+
+//ReqPlayerAchievements :
+type ReqPlayerAchievements struct {
+	DeviceID string `json:"device_id"`
+}
+
+//ResAchievement : a configured achievement, with its grant if the player earned it
+type ResAchievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Earned      bool   `json:"earned"`
+	MatchID     string `json:"match_id,omitempty"`
+	GrantedTime string `json:"granted_time,omitempty"`
+}
+
+//ResHistoryMatch :
+type ResHistoryMatch struct {
+	MatchID     string `json:"match_id"`
+	MatchDate   string `json:"match_date"`
+	MatchStatus string `json:"match_status"`
+	GameMode    string `json:"game_mode,omitempty"`
+	SeasonID    string `json:"season_id,omitempty"`
+	Result      string `json:"result,omitempty"`
+	EndReason   string `json:"end_reason,omitempty"`
+	EnemyID     string `json:"enemy_id"`
+	EnemyName   string `json:"enemy_name,omitempty"`
+	EnemyNation string `json:"enemy_nation,omitempty"`
+	EnemyMMR    int64  `json:"enemy_mmr"`
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"earthshaker/api/config"
+	"earthshaker/api/dao"
+	"earthshaker/api/models"
+	"log"
+	"os"
+)
+
+//Comment :
+const (
+	MigratorPageSize = 500
+)
+
+var (
+	logger     *log.Logger
+	cfg        = config.Config{}
+	accountDAO = dao.AccountDAO{}
+)
+
+func init() {
+	logger = log.New(os.Stderr, "ERR: ", log.Ldate|log.Ltime|log.Lshortfile)
+
+	cfg.Read()
+	dao.Setup(cfg.Database)
+	dao.Connect(cfg.AtlasURI)
+
+	accountDAO.Setup()
+}
+
+func main() {
+	defer dao.Disconnect()
+	logger.Println("Start account migrator.")
+	for _, collection := range []string{"device_info", "player_status"} {
+		checked, err := MigrateAccounts(collection)
+		if err != nil {
+			logger.Println(err)
+			return
+		}
+		logger.Printf("Accounts of %s migrated, %d devices checked.", collection, checked)
+	}
+}
+
+//MigrateAccounts : create an account for each device of the collection which has none,
+//the player id of the account is the device id, so the data of the device stays with it.
+//Devices with an account are left as they are, so the migration can be run again.
+func MigrateAccounts(collection string) (int64, error) {
+	var checked int64
+	var after *models.Device
+	for {
+		devices, err := accountDAO.FindDevicesIn(collection, after, MigratorPageSize)
+		if err != nil {
+			return checked, err
+		}
+		for idx := range devices {
+			device := devices[idx]
+			after = &devices[idx]
+			if len(device.DeviceID) == 0 {
+				continue
+			}
+			if err := accountDAO.Create(device.DeviceID); err != nil {
+				return checked, err
+			}
+			checked++
+		}
+		if len(devices) < MigratorPageSize {
+			return checked, nil
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"earthshaker/api/config"
 	"earthshaker/api/dao"
+	"earthshaker/api/helper"
 	"earthshaker/api/models"
 	"log"
 	"os"
@@ -11,7 +12,8 @@ import (
 
 //Comment :
 const (
-	DurationBeforeNow = 10 * time.Minute
+	DurationBeforeNow   = 10 * time.Minute
+	AchievementPageSize = 100
 )
 
 var (
@@ -19,6 +21,8 @@ var (
 	cfg       = config.Config{}
 	statusDAO = dao.StatusDAO{}
 	matchDAO  = dao.MatchDAO{}
+
+	achievementDAO = dao.AchievementDAO{}
 )
 
 func init() {
@@ -30,6 +34,7 @@ func init() {
 
 	statusDAO.Setup()
 	matchDAO.Setup()
+	achievementDAO.Setup()
 }
 
 func main() {
@@ -41,6 +46,11 @@ func main() {
 			logger.Println(err)
 			break
 		}
+		err = EvaluateAchievements()
+		if err != nil {
+			logger.Println(err)
+			break
+		}
 		time.Sleep(DurationBeforeNow)
 	}
 }
@@ -55,52 +65,74 @@ func CleanMatchUpdateMMR() error {
 	var updatingMatches []models.Match
 	for _, match := range matches {
 		match.UpdatedTime = time.Now()
-		if match.MatchStatus == models.WAIT || match.MatchStatus == models.INIT {
-			match.MatchStatus = models.ERR
-			updatingMatches = append(updatingMatches, match)
-		} else {
-			if len(match.WinnerID) > 0 && len(match.LoserID) > 0 && match.WinnerID != match.LoserID {
-				match.MatchStatus = models.END
-				updatingMatches = append(updatingMatches, match)
-				IncreaseMMR(&playerMMRs, match.WinnerID)
-			} else if len(match.WinnerID) == 0 && len(match.LoserID) > 0 {
-				if match.LoserID == match.Device1ID {
-					match.WinnerID = match.Device2ID
-					match.MatchStatus = models.END
-					updatingMatches = append(updatingMatches, match)
-					IncreaseMMR(&playerMMRs, match.WinnerID)
-				} else if match.LoserID == match.Device2ID {
-					match.WinnerID = match.Device1ID
-					match.MatchStatus = models.END
-					updatingMatches = append(updatingMatches, match)
-					IncreaseMMR(&playerMMRs, match.WinnerID)
-				} else {
-					match.MatchStatus = models.INV
-					updatingMatches = append(updatingMatches, match)
+		if winnerID := helper.ResolveMatch(&match); len(winnerID) > 0 {
+			IncreaseMMR(&playerMMRs, winnerID)
+		}
+		updatingMatches = append(updatingMatches, match)
+	}
+
+	return dao.VerifyAndUpdateMMR(updatingMatches, playerMMRs, models.Audit{
+		ActorType: models.ACTORCRON,
+		ActorID:   "matchcleaner",
+	})
+}
+
+//EvaluateAchievements : grant the achievements earned by the players of the ended matches, then mark the matches.
+//A grant is kept once, so evaluating a match again never grants an achievement twice.
+func EvaluateAchievements() error {
+	for {
+		matches, err := matchDAO.FindUnachievedMatches(AchievementPageSize)
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			players := [][2]string{{match.Device1ID, match.Device2ID}, {match.Device2ID, match.Device1ID}}
+			for _, player := range players {
+				deviceID, opponentID := player[0], player[1]
+				progress, err := ProgressOf(deviceID, opponentID, match)
+				if err != nil {
+					return err
 				}
-			} else if len(match.WinnerID) > 0 && len(match.LoserID) == 0 {
-				if match.WinnerID == match.Device1ID {
-					match.LoserID = match.Device2ID
-					match.MatchStatus = models.END
-					updatingMatches = append(updatingMatches, match)
-					IncreaseMMR(&playerMMRs, match.WinnerID)
-				} else if match.WinnerID == match.Device2ID {
-					match.LoserID = match.Device1ID
-					match.MatchStatus = models.END
-					updatingMatches = append(updatingMatches, match)
-					IncreaseMMR(&playerMMRs, match.WinnerID)
-				} else {
-					match.MatchStatus = models.INV
-					updatingMatches = append(updatingMatches, match)
+				for _, achievement := range cfg.Achievements {
+					if !helper.AchievementMet(achievement.Metric, achievement.Threshold, progress) {
+						continue
+					}
+					if err := achievementDAO.Grant(deviceID, achievement.ID, match.ID.Hex()); err != nil {
+						return err
+					}
 				}
-			} else {
-				match.MatchStatus = models.INV
-				updatingMatches = append(updatingMatches, match)
+			}
+			if err := matchDAO.MarkAchieved(match.ID); err != nil {
+				return err
 			}
 		}
+		if len(matches) < AchievementPageSize {
+			return nil
+		}
 	}
+}
 
-	return dao.VerifyAndUpdateMMR(updatingMatches, playerMMRs)
+//ProgressOf : the metrics of a player as of a match, counting the matches created until it,
+//the rank of a beaten opponent is the one recorded when the match was made.
+func ProgressOf(deviceID string, opponentID string, match models.Match) (models.Progress, error) {
+	var progress models.Progress
+	stats, err := matchDAO.AggregateStatsOf(deviceID, "", "", match.CreatedTime, 1)
+	if err != nil {
+		return progress, err
+	}
+	progress.Wins = stats.Wins
+	progress.BestStreak = stats.BestStreak
+	if progress.NationsPlayed, err = matchDAO.CountOpponentNationsOf(deviceID, match.CreatedTime); err != nil {
+		return progress, err
+	}
+	if match.WinnerID == deviceID {
+		if opponentID == match.Device1ID {
+			progress.BeatRank = match.Device1Rank
+		} else {
+			progress.BeatRank = match.Device2Rank
+		}
+	}
+	return progress, nil
 }
 
 //IncreaseMMR :
@@ -3,6 +3,7 @@ package main
 import (
 	"earthshaker/api/config"
 	"earthshaker/api/dao"
+	"earthshaker/api/helper"
 	"earthshaker/api/models"
 	"log"
 	"os"
@@ -17,10 +18,14 @@ const (
 )
 
 var (
-	logger    *log.Logger
-	cfg       = config.Config{}
-	statusDAO = dao.StatusDAO{}
-	matchDAO  = dao.MatchDAO{}
+	logger      *log.Logger
+	cfg         = config.Config{}
+	statusDAO   = dao.StatusDAO{}
+	matchDAO    = dao.MatchDAO{}
+	sanctionDAO = dao.SanctionDAO{}
+	seasonDAO   = dao.SeasonDAO{}
+	relationDAO = dao.RelationDAO{}
+	snapshotDAO = dao.SnapshotDAO{}
 
 	interval = MinInterval
 )
@@ -34,6 +39,10 @@ func init() {
 
 	statusDAO.Setup()
 	matchDAO.Setup()
+	sanctionDAO.Setup()
+	seasonDAO.Setup()
+	relationDAO.Setup()
+	snapshotDAO.Setup()
 }
 
 func main() {
@@ -59,37 +68,117 @@ func MakeMatch() error {
 		return err
 	}
 
+	// - Sanctioned players are taken out of the queue
+	var deviceIDs []string
+	for _, player := range players {
+		deviceIDs = append(deviceIDs, player.DeviceID)
+	}
+	sanctions, err := sanctionDAO.FindActiveOfAny(deviceIDs)
+	if err != nil {
+		return err
+	}
+	sanctionsOf := map[string][]models.Sanction{}
+	for _, sanction := range sanctions {
+		sanctionsOf[sanction.DeviceID] = append(sanctionsOf[sanction.DeviceID], sanction)
+	}
+
+	// - Players who blocked each other are never paired
+	blocks, err := relationDAO.FindBlocksAmong(deviceIDs)
+	if err != nil {
+		return err
+	}
+	blocked := map[[2]string]bool{}
+	for _, block := range blocks {
+		blocked[[2]string{block.DeviceID, block.TargetID}] = true
+		blocked[[2]string{block.TargetID, block.DeviceID}] = true
+	}
+
+	// - Only players waiting for the same game mode are paired
 	var updatingPlayers []models.Status
+	var gameModes []string
+	playersOf := map[string][]models.Status{}
+	for _, player := range players {
+		gameMode := player.GameMode
+		if !cfg.IsGameMode(gameMode) {
+			gameMode = cfg.DefaultGameMode
+		}
+		if helper.BlockingSanction(sanctionsOf[player.DeviceID], cfg.GameModeOf(gameMode).Ranked) != nil {
+			player.PlayerStatus = models.ONLINE
+			updatingPlayers = append(updatingPlayers, player)
+			continue
+		}
+		if _, exist := playersOf[gameMode]; !exist {
+			gameModes = append(gameModes, gameMode)
+		}
+		playersOf[gameMode] = append(playersOf[gameMode], player)
+	}
+
+	// - Matches belong to the running season, if any
+	var seasonID string
+	season, err := seasonDAO.FindCurrent(time.Now())
+	if err == nil {
+		seasonID = season.SeasonID
+	} else if err.Error() != "NotFound" {
+		return err
+	}
+
+	// - Matches keep the ranks of their players, achievements are evaluated against them
+	ranks, err := snapshotDAO.RanksOf(deviceIDs)
+	if err != nil {
+		return err
+	}
+
 	var creatingMatches []models.Match
-	for len(players) >= MatchSize {
-		player1, player2 := players[0], players[1]
-		// logger.Printf("Player1 %+v", player1)
-		// logger.Printf("Player2 %+v", player2)
-		players = players[2:]
-		newMatch := models.Match{
-			MatchStatus: models.INIT,
-			Device1ID:   player1.DeviceID,
-			Device2ID:   player2.DeviceID,
-			FirstTurnID: player2.DeviceID,
-			CreatedTime: time.Now(),
-			UpdatedTime: time.Now(),
+	for _, gameMode := range gameModes {
+		players := playersOf[gameMode]
+		for len(players) >= MatchSize {
+			player1 := players[0]
+			idx := 1
+			for idx < len(players) && blocked[[2]string{player1.DeviceID, players[idx].DeviceID}] {
+				idx++
+			}
+			if idx == len(players) {
+				// - Nobody left for this player, it keeps waiting for the next round
+				players = players[1:]
+				continue
+			}
+			player2 := players[idx]
+			// logger.Printf("Player1 %+v", player1)
+			// logger.Printf("Player2 %+v", player2)
+			players = append(players[1:idx], players[idx+1:]...)
+			newMatch := models.Match{
+				MatchStatus: models.INIT,
+				GameMode:    gameMode,
+				SeasonID:    seasonID,
+				Device1ID:   player1.DeviceID,
+				Device2ID:   player2.DeviceID,
+				Device1Rank: ranks[player1.DeviceID],
+				Device2Rank: ranks[player2.DeviceID],
+				FirstTurnID: player2.DeviceID,
+				CreatedTime: time.Now(),
+				UpdatedTime: time.Now(),
+			}
+			// logger.Printf("New Match %+v", newMatch)
+			creatingMatches = append(creatingMatches, newMatch)
+			player1.PlayerStatus = models.INMATCH
+			player2.PlayerStatus = models.INMATCH
+			updatingPlayers = append(updatingPlayers, player1, player2)
+		}
+	}
+	if len(updatingPlayers) > 0 {
+		err := dao.CreateMatches(&updatingPlayers, &creatingMatches, models.Audit{
+			ActorType: models.ACTORCRON,
+			ActorID:   "matchmaker",
+		})
+		if err != nil {
+			return err
 		}
-		// logger.Printf("New Match %+v", newMatch)
-		creatingMatches = append(creatingMatches, newMatch)
-		player1.PlayerStatus = models.INMATCH
-		player2.PlayerStatus = models.INMATCH
-		updatingPlayers = append(updatingPlayers, player1, player2)
 	}
 	if len(creatingMatches) > 0 {
 		interval -= MinInterval
 		if interval < MinInterval {
 			interval = MinInterval
 		}
-
-		err := dao.CreateMatches(&updatingPlayers, &creatingMatches)
-		if err != nil {
-			return err
-		}
 	} else {
 		interval += MinInterval
 		if interval > MaxInterval {
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"earthshaker/api/config"
+	"earthshaker/api/dao"
+	"earthshaker/api/helper"
+	"earthshaker/api/models"
+	"log"
+	"os"
+	"time"
+)
+
+//Comment :
+const (
+	TimerInterval = 2 * time.Second
+)
+
+var (
+	logger   *log.Logger
+	cfg      = config.Config{}
+	matchDAO = dao.MatchDAO{}
+	auditDAO = dao.AuditDAO{}
+)
+
+func init() {
+	logger = log.New(os.Stderr, "ERR: ", log.Ldate|log.Ltime|log.Lshortfile)
+
+	cfg.Read()
+	dao.Setup(cfg.Database)
+	dao.Connect(cfg.AtlasURI)
+
+	matchDAO.Setup()
+	auditDAO.Setup()
+}
+
+func main() {
+	defer dao.Disconnect()
+	logger.Println("Start match timer service.")
+	for {
+		err := TimeoutMatches()
+		if err != nil {
+			logger.Println(err)
+			break
+		}
+		err = ForfeitDisconnectedMatches()
+		if err != nil {
+			logger.Println(err)
+			break
+		}
+		time.Sleep(TimerInterval)
+	}
+}
+
+//TimeoutMatches : the player whose clock has run out loses, the match cleaner ends the match later.
+func TimeoutMatches() error {
+	matches, err := matchDAO.FindUndecidedMatches()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, match := range matches {
+		gameMode := cfg.GameModeOf(match.GameMode)
+		loserID := helper.TimedOutPlayer(match, gameMode.TurnLimit(), gameMode.MatchLimit(), now)
+		if len(loserID) == 0 {
+			continue
+		}
+		var matchModel = models.Match{
+			ID:        match.ID,
+			LoserID:   loserID,
+			EndReason: models.TIMEOUT,
+		}
+		if matchModel.WinnerID = match.Device1ID; loserID == match.Device1ID {
+			matchModel.WinnerID = match.Device2ID
+		}
+		if err := matchDAO.DecideResult(matchModel); err != nil {
+			if err.Error() == "Decided" {
+				// - A player reported a result meanwhile, it is kept
+				continue
+			}
+			return err
+		}
+		if err := auditDAO.Insert(models.Audit{
+			ActorType: models.ACTORCRON,
+			ActorID:   "matchtimer",
+			Action:    models.AUDITRESOLVEMATCH,
+			TargetID:  match.ID.Hex(),
+			After: map[string]interface{}{
+				"winner_id":  matchModel.WinnerID,
+				"loser_id":   matchModel.LoserID,
+				"end_reason": matchModel.EndReason,
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//ForfeitDisconnectedMatches : a player who has not reconnected within the grace period loses the match.
+func ForfeitDisconnectedMatches() error {
+	grace := time.Duration(cfg.ReconnectGraceSeconds) * time.Second
+	matches, err := matchDAO.FindDisconnectedMatches(grace)
+	if err != nil {
+		return err
+	}
+	pivotTime := time.Now().Add(-grace)
+	for _, match := range matches {
+		device1Gone := match.Device1SeenTime.Before(pivotTime)
+		device2Gone := match.Device2SeenTime.Before(pivotTime)
+		var matchModel = models.Match{
+			ID: match.ID,
+		}
+		if device1Gone && device2Gone {
+			matchModel.MatchStatus = models.ERR
+			matchModel.EndReason = models.ABANDON
+		} else if device1Gone {
+			matchModel.WinnerID = match.Device2ID
+			matchModel.LoserID = match.Device1ID
+			matchModel.EndReason = models.FORFEIT
+		} else {
+			matchModel.WinnerID = match.Device1ID
+			matchModel.LoserID = match.Device2ID
+			matchModel.EndReason = models.FORFEIT
+		}
+		if err := matchDAO.DecideResult(matchModel); err != nil {
+			if err.Error() == "Decided" {
+				continue
+			}
+			return err
+		}
+		before, after := dao.ChangedFields(map[string]interface{}{
+			"match_status": match.MatchStatus,
+			"winner_id":    match.WinnerID,
+			"loser_id":     match.LoserID,
+		}, map[string]interface{}{
+			"match_status": matchModel.MatchStatus,
+			"winner_id":    matchModel.WinnerID,
+			"loser_id":     matchModel.LoserID,
+			"end_reason":   matchModel.EndReason,
+		})
+		if err := auditDAO.Insert(models.Audit{
+			ActorType: models.ACTORCRON,
+			ActorID:   "matchcleaner",
+			Action:    models.AUDITRESOLVEMATCH,
+			TargetID:  match.ID.Hex(),
+			Before:    before,
+			After:     after,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"earthshaker/api/config"
+	"earthshaker/api/dao"
+	"earthshaker/api/models"
+	"log"
+	"os"
+	"time"
+)
+
+//Comment :
+const (
+	SnapshotPageSize = 1000
+)
+
+var (
+	logger      *log.Logger
+	cfg         = config.Config{}
+	statusDAO   = dao.StatusDAO{}
+	snapshotDAO = dao.SnapshotDAO{}
+)
+
+func init() {
+	logger = log.New(os.Stderr, "ERR: ", log.Ldate|log.Ltime|log.Lshortfile)
+
+	cfg.Read()
+	dao.Setup(cfg.Database)
+	dao.Connect(cfg.AtlasURI)
+
+	statusDAO.Setup()
+	snapshotDAO.Setup()
+}
+
+func main() {
+	defer dao.Disconnect()
+	logger.Println("Start rank snapshot service.")
+	for {
+		err := TakeRankSnapshot()
+		if err != nil {
+			logger.Println(err)
+			break
+		}
+		time.Sleep(time.Duration(cfg.RankSnapshotMinutes) * time.Minute)
+	}
+}
+
+//TakeRankSnapshot : save the rank, nation rank and percentile of every player in rank order,
+//then drop the snapshots of players who are gone. The mmr of the players is copied first in device id order,
+//so players whose mmr moves meanwhile are neither skipped nor ranked twice, then the copies are ranked.
+func TakeRankSnapshot() error {
+	// - Mongo keeps milliseconds, the time must compare equal once saved
+	snapshotTime := time.Now().Truncate(time.Millisecond)
+	if err := FreezeRanks(snapshotTime); err != nil {
+		return err
+	}
+	total, err := snapshotDAO.CountFrozen(snapshotTime)
+	if err != nil {
+		return err
+	}
+	var after *models.FrozenRank
+	var rank int64
+	nationRanks := map[string]int64{}
+	for {
+		players, err := snapshotDAO.FindFrozenAfter(snapshotTime, after, SnapshotPageSize)
+		if err != nil {
+			return err
+		}
+		var snapshots []models.RankSnapshot
+		for _, player := range players {
+			rank++
+			snapshot := models.RankSnapshot{
+				DeviceID:     player.DeviceID,
+				Rank:         rank,
+				Percentile:   float64(total-rank+1) * 100 / float64(total),
+				PlayerMMR:    player.PlayerMMR,
+				TotalPlayers: total,
+				SnapshotTime: snapshotTime,
+			}
+			if len(player.PlayerNation) > 0 {
+				nationRanks[player.PlayerNation]++
+				snapshot.NationRank = nationRanks[player.PlayerNation]
+			}
+			snapshots = append(snapshots, snapshot)
+		}
+		if err := snapshotDAO.SaveAll(snapshots); err != nil {
+			return err
+		}
+		if len(players) < SnapshotPageSize {
+			break
+		}
+		after = &players[len(players)-1]
+	}
+	if err := snapshotDAO.DeleteBefore(snapshotTime); err != nil {
+		return err
+	}
+	return snapshotDAO.DeleteFrozen(snapshotTime)
+}
+
+//FreezeRanks : copy the nation and mmr of every player for the snapshot taken at the time.
+func FreezeRanks(snapshotTime time.Time) error {
+	var after string
+	for {
+		players, err := statusDAO.FindAfter(after, SnapshotPageSize)
+		if err != nil {
+			return err
+		}
+		if err := snapshotDAO.Freeze(players, snapshotTime); err != nil {
+			return err
+		}
+		if len(players) < SnapshotPageSize {
+			return nil
+		}
+		after = players[len(players)-1].DeviceID
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"earthshaker/api/config"
+	"earthshaker/api/dao"
+	"earthshaker/api/helper"
+	"earthshaker/api/models"
+	"log"
+	"os"
+	"time"
+)
+
+//Comment :
+const (
+	RollerInterval = 10 * time.Minute
+	RollerPageSize = 500
+)
+
+var (
+	logger          *log.Logger
+	cfg             = config.Config{}
+	statusDAO       = dao.StatusDAO{}
+	seasonDAO       = dao.SeasonDAO{}
+	seasonRecordDAO = dao.SeasonRecordDAO{}
+)
+
+func init() {
+	logger = log.New(os.Stderr, "ERR: ", log.Ldate|log.Ltime|log.Lshortfile)
+
+	cfg.Read()
+	dao.Setup(cfg.Database)
+	dao.Connect(cfg.AtlasURI)
+
+	statusDAO.Setup()
+	seasonDAO.Setup()
+	seasonRecordDAO.Setup()
+}
+
+func main() {
+	defer dao.Disconnect()
+	logger.Println("Start season roller service.")
+	for {
+		err := RollOverSeason()
+		if err != nil {
+			logger.Println(err)
+			break
+		}
+		time.Sleep(RollerInterval)
+	}
+}
+
+//RollOverSeason : archive the final ranks of an ended season, then soft reset the mmr of its players.
+//Both steps can be run again if the service stops in the middle.
+func RollOverSeason() error {
+	season, err := seasonDAO.FindEnded(time.Now())
+	if err != nil {
+		if err.Error() == "NotFound" {
+			return nil
+		}
+		return err
+	}
+	if season.ArchivedTime.IsZero() {
+		if err := ArchiveSeason(season); err != nil {
+			return err
+		}
+		if err := seasonDAO.MarkArchived(season.SeasonID); err != nil {
+			return err
+		}
+	}
+	for {
+		records, err := seasonRecordDAO.FindNotReset(season.SeasonID, RollerPageSize)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			break
+		}
+		err = dao.ApplySeasonReset(records, models.Audit{
+			ActorType: models.ACTORCRON,
+			ActorID:   "seasonroller",
+		})
+		if err != nil {
+			return err
+		}
+	}
+	logger.Printf("Season %s is rolled over.", season.SeasonID)
+	return seasonDAO.MarkRolledOver(season.SeasonID)
+}
+
+//ArchiveSeason : save the rank, nation rank and mmr of every player, and the mmr after the soft reset.
+//The mmr of the players is frozen first, in device id order so players whose mmr moves meanwhile are neither
+//skipped nor saved twice, then the players are ranked by their frozen mmr.
+func ArchiveSeason(season models.Season) error {
+	if err := FreezeSeason(season); err != nil {
+		return err
+	}
+	return RankSeason(season)
+}
+
+//FreezeSeason : save the name, nation and mmr of every player in a record of the season.
+func FreezeSeason(season models.Season) error {
+	var after string
+	for {
+		players, err := statusDAO.FindAfter(after, RollerPageSize)
+		if err != nil {
+			return err
+		}
+		for _, player := range players {
+			record := models.SeasonRecord{
+				SeasonID:     season.SeasonID,
+				DeviceID:     player.DeviceID,
+				PlayerName:   player.PlayerName,
+				PlayerNation: player.PlayerNation,
+				FinalMMR:     player.PlayerMMR,
+				ResetMMR:     helper.SoftResetMMR(player.PlayerMMR, cfg.SeasonBaseMMR, cfg.SeasonResetFactor),
+			}
+			if err := seasonRecordDAO.Freeze(record); err != nil {
+				return err
+			}
+		}
+		if len(players) < RollerPageSize {
+			return nil
+		}
+		after = players[len(players)-1].DeviceID
+	}
+}
+
+//RankSeason : rank the records of the season by their frozen mmr, overall and in their nation.
+func RankSeason(season models.Season) error {
+	var after *models.SeasonRecord
+	var rank int64
+	nationRanks := map[string]int64{}
+	for {
+		records, err := seasonRecordDAO.FindFrozenAfter(season.SeasonID, after, RollerPageSize)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			rank++
+			record.FinalRank = rank
+			if len(record.PlayerNation) > 0 {
+				nationRanks[record.PlayerNation]++
+				record.FinalNationRank = nationRanks[record.PlayerNation]
+			}
+			if err := seasonRecordDAO.SetRanks(record); err != nil {
+				return err
+			}
+		}
+		if len(records) < RollerPageSize {
+			return nil
+		}
+		after = &records[len(records)-1]
+	}
+}